@@ -2,260 +2,1477 @@ package integresql
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/allaboutapps/integresql-client-go/pkg/models"
+)
+
+var (
+	ErrManagerNotReady            = errors.New("manager not ready")
+	ErrTemplateAlreadyInitialized = errors.New("template is already initialized")
+	ErrTemplateNotFound           = errors.New("template not found")
+	ErrDatabaseDiscarded          = errors.New("database was discarded (typically failed during initialize/finalize)")
+	ErrTestNotFound               = errors.New("test database not found")
+	ErrClientClosed               = errors.New("client is closed")
+	ErrAPIVersionNotSupported     = errors.New("none of the API versions supported by the client are provided by the manager")
+	ErrResponseTooLarge           = errors.New("response body too large")
+	ErrTemplateHashCollision      = errors.New("template hash was already recorded with a different fingerprint")
+	ErrPoolExhausted              = errors.New("pool of test databases is temporarily exhausted")
+	ErrUnexpectedStatus           = errors.New("unexpected HTTP status")
+)
+
+// APIError is returned if the manager responded with an unexpected HTTP status, carrying the raw response body.
+// It matches ErrUnexpectedStatus via errors.Is, use errors.As to access the status code.
+type APIError struct {
+	StatusCode int
+	Status     string
+	Body       string
+	Message    string // error message reported by the manager, if the body contained a JSON error response
+	RequestID  string // ID sent via the X-Request-ID header, allowing to correlate the error with the manager's logs
+}
+
+// NotReadyError is returned if the manager responded with 503 Service Unavailable, matching ErrManagerNotReady via
+// errors.Is. RetryAfter holds the delay suggested via the Retry-After header, allowing callers to retry on their own.
+type NotReadyError struct {
+	RetryAfter time.Duration // zero if the manager did not send a Retry-After header
+}
+
+func (e *NotReadyError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%v (retry after %v)", ErrManagerNotReady, e.RetryAfter)
+	}
+
+	return ErrManagerNotReady.Error()
+}
+
+func (e *NotReadyError) Is(target error) bool {
+	return target == ErrManagerNotReady
+}
+
+// newNotReadyError creates a NotReadyError from the given 503 Service Unavailable response.
+func newNotReadyError(resp *http.Response) error {
+	retryAfter, _ := parseRetryAfter(resp)
+
+	return &NotReadyError{RetryAfter: retryAfter}
+}
+
+// parseRetryAfter returns the delay suggested by the Retry-After header of resp, given either in seconds or as HTTP date.
+func parseRetryAfter(resp *http.Response) (time.Duration, bool) {
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if len(value) == 0 {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	delay := time.Until(date)
+	if delay < 0 {
+		delay = 0
+	}
+
+	return delay, true
+}
+
+// errorResponse is the JSON body returned by the manager for failed requests.
+type errorResponse struct {
+	Message string `json:"message"`
+}
+
+// maxErrorBodyLength limits the number of bytes of the response body included in an APIError's message.
+const maxErrorBodyLength = 512
+
+func (e *APIError) Error() string {
+	if len(e.Message) > 0 {
+		return fmt.Sprintf("received unexpected HTTP status %d (%s): %s", e.StatusCode, e.Status, e.Message)
+	}
+
+	body := strings.TrimSpace(e.Body)
+	if len(body) == 0 {
+		return fmt.Sprintf("received unexpected HTTP status %d (%s)", e.StatusCode, e.Status)
+	}
+
+	if len(body) > maxErrorBodyLength {
+		body = body[:maxErrorBodyLength] + "..."
+	}
+
+	return fmt.Sprintf("received unexpected HTTP status %d (%s): %s", e.StatusCode, e.Status, body)
+}
+
+func (e *APIError) Is(target error) bool {
+	return target == ErrUnexpectedStatus
+}
+
+// newAPIError creates an APIError from the given response, whose body has already been buffered by send.
+func newAPIError(resp *http.Response) error {
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       string(body),
+	}
+
+	if resp.Request != nil {
+		apiErr.RequestID = resp.Request.Header.Get(requestIDHeader)
+	}
+
+	var errResp errorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil {
+		apiErr.Message = errResp.Message
+	}
+
+	return apiErr
+}
+
+type Client struct {
+	rootURL *url.URL // configured BaseURL joined with PathPrefix, excluding the API version
+	baseURL *url.URL
+	config  ClientConfig
+	dbs     *dbCache
+
+	// mu guards all fields below, allowing to reconfigure the client via SetClient/SetDefaultHeader while in use
+	mu           sync.RWMutex
+	client       *http.Client
+	headers      http.Header
+	closed       bool
+	fingerprints map[string]string // fingerprints recorded by VerifyTemplateHash, keyed by template hash
+
+	ctx context.Context // base context used by the *Default convenience methods, see NewClientWithContext
+}
+
+func NewClient(config ClientConfig) (*Client, error) {
+	c := &Client{
+		baseURL: nil,
+		client:  nil,
+		config:  config,
+		dbs:     newDBCache(maxCachedTestDatabases),
+		ctx:     context.Background(),
+	}
+
+	defaultConfig := DefaultClientConfigFromEnv()
+
+	if len(c.config.BaseURL) == 0 {
+		c.config.BaseURL = defaultConfig.BaseURL
+	}
+
+	if len(c.config.APIVersion) == 0 {
+		c.config.APIVersion = defaultConfig.APIVersion
+	}
+
+	if c.config.RetryBackoff == 0 {
+		c.config.RetryBackoff = defaultConfig.RetryBackoff
+	}
+
+	if len(c.config.AuthToken) == 0 {
+		c.config.AuthToken = defaultConfig.AuthToken
+	}
+
+	if len(c.config.Username) == 0 && len(c.config.Password) == 0 {
+		c.config.Username = defaultConfig.Username
+		c.config.Password = defaultConfig.Password
+	}
+
+	if c.config.Timeout == 0 {
+		c.config.Timeout = defaultConfig.Timeout
+	}
+
+	if len(c.config.DriverName) == 0 {
+		c.config.DriverName = defaultConfig.DriverName
+	}
+
+	if c.config.GetTestDatabaseBackoff == 0 {
+		c.config.GetTestDatabaseBackoff = defaultConfig.GetTestDatabaseBackoff
+	}
+
+	if c.config.PingBackoff == 0 {
+		c.config.PingBackoff = defaultConfig.PingBackoff
+	}
+
+	if len(c.config.Proxy) == 0 {
+		c.config.Proxy = defaultConfig.Proxy
+	}
+
+	if len(c.config.OverrideHost) == 0 {
+		c.config.OverrideHost = defaultConfig.OverrideHost
+	}
+
+	if c.config.OverridePort == 0 {
+		c.config.OverridePort = defaultConfig.OverridePort
+	}
+
+	if !c.config.DiscardTemplateOnInitError {
+		c.config.DiscardTemplateOnInitError = defaultConfig.DiscardTemplateOnInitError
+	}
+
+	if !c.config.UnlockOnRelease {
+		c.config.UnlockOnRelease = defaultConfig.UnlockOnRelease
+	}
+
+	if !c.config.RetryNonIdempotentOnReset {
+		c.config.RetryNonIdempotentOnReset = defaultConfig.RetryNonIdempotentOnReset
+	}
+
+	if !c.config.StrictJSON {
+		c.config.StrictJSON = defaultConfig.StrictJSON
+	}
+
+	if c.config.MaxResponseBytes == 0 {
+		c.config.MaxResponseBytes = defaultConfig.MaxResponseBytes
+	}
+
+	if len(c.config.UserAgent) == 0 {
+		c.config.UserAgent = defaultConfig.UserAgent
+	}
+
+	if len(c.config.PathPrefix) == 0 {
+		c.config.PathPrefix = defaultConfig.PathPrefix
+	}
+
+	if err := c.config.Validate(); err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(c.config.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(c.config.PathPrefix) > 0 {
+		u.Path = path.Join("/", u.Path, c.config.PathPrefix)
+		u.RawPath = ""
+	}
+
+	// tolerate BaseURL already including the API version, which would otherwise be appended twice
+	if p := path.Clean(u.Path); path.Base(p) == c.config.APIVersion {
+		u.Path = path.Dir(p)
+		u.RawPath = ""
+	}
+
+	c.rootURL = u
+	c.baseURL = u.ResolveReference(&url.URL{Path: path.Join(u.Path, c.config.APIVersion)})
+
+	transport, err := newTransport(c.config)
+	if err != nil {
+		return nil, err
+	}
+
+	c.client = &http.Client{
+		Transport: transport,
+		Timeout:   c.config.Timeout,
+	}
+
+	c.config.OperationTimeouts = cloneOperationTimeouts(c.config.OperationTimeouts)
+
+	c.headers = c.config.Headers.Clone()
+	if c.headers == nil {
+		c.headers = http.Header{}
+	}
+
+	return c, nil
+}
+
+// newTransport creates a transport applying the given config's TLS and proxy settings, returning nil
+// to use http.DefaultTransport (respecting HTTP_PROXY/NO_PROXY) if no custom settings are required.
+func newTransport(config ClientConfig) (http.RoundTripper, error) {
+	if config.TLSConfig == nil && len(config.Proxy) == 0 {
+		return nil, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if config.TLSConfig != nil {
+		transport.TLSClientConfig = config.TLSConfig.Clone()
+	}
+
+	if len(config.Proxy) > 0 {
+		proxy, err := url.Parse(config.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL %q: %w", config.Proxy, err)
+		}
+
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	return transport, nil
+}
+
+func DefaultClientFromEnv() (*Client, error) {
+	return NewClient(DefaultClientConfigFromEnv())
+}
+
+// BaseURL returns a copy of the resolved base URL requests are sent to, including the API version.
+func (c *Client) BaseURL() *url.URL {
+	u := *c.baseURL
+
+	return &u
+}
+
+// Config returns a copy of the configuration the client was created with, including any defaults applied.
+func (c *Client) Config() ClientConfig {
+	config := c.config
+	config.Headers = c.config.Headers.Clone()
+	config.OperationTimeouts = cloneOperationTimeouts(c.config.OperationTimeouts)
+
+	return config
+}
+
+func cloneOperationTimeouts(timeouts map[string]time.Duration) map[string]time.Duration {
+	if timeouts == nil {
+		return nil
+	}
+
+	clone := make(map[string]time.Duration, len(timeouts))
+	for name, timeout := range timeouts {
+		clone[name] = timeout
+	}
+
+	return clone
+}
+
+// Clone returns a copy of the client sharing its configuration and underlying *http.Client, and thus the
+// transport and its connection pool, while default headers set via SetDefaultHeader afterwards only apply to
+// the respective client. Cloning is considerably cheaper than creating a new client, e.g. per tenant or test.
+// Connection pools opened via OpenTestDatabase are not shared, closing a clone however closes the idle
+// connections of the shared transport.
+func (c *Client) Clone() *Client {
+	rootURL := *c.rootURL
+	baseURL := *c.baseURL
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return &Client{
+		rootURL: &rootURL,
+		baseURL: &baseURL,
+		client:  c.client,
+		config:  c.Config(),
+		headers: c.headers.Clone(),
+		dbs:     newDBCache(maxCachedTestDatabases),
+		closed:  c.closed,
+		ctx:     c.ctx,
+	}
+}
+
+// HTTPClient returns the HTTP client used to send requests to the manager, e.g. to inspect its timeout or to derive
+// a client passed to SetClient from it.
+func (c *Client) HTTPClient() *http.Client {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.client
+}
+
+// SetClient replaces the HTTP client used to send requests to the manager. If client has no timeout set, a copy of
+// it using the configured Timeout is used instead, so replacing the HTTP client does not silently drop the timeout.
+// Configuring the client should ideally happen before using it, replacing the HTTP client is however safe even
+// while requests are in flight.
+func (c *Client) SetClient(client *http.Client) {
+	if client != nil && client.Timeout == 0 && c.config.Timeout > 0 {
+		copied := *client
+		copied.Timeout = c.config.Timeout
+		client = &copied
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.client = client
+}
+
+// SetDefaultHeader sets a header sent with every request to the manager, replacing any existing values of key.
+// Like SetClient, it is safe to be called concurrently with requests, which are sent with the headers set when
+// they were created.
+func (c *Client) SetDefaultHeader(key, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.headers.Set(key, value)
+}
+
+// Close closes the client, see CloseContext.
+//
+// Deprecated: use CloseContext instead, which reports errors while closing the client.
+func (c *Client) Close() {
+	_ = c.CloseContext(context.Background())
+}
+
+// CloseContext closes the client, all idle connections to the manager and all connection pools opened via
+// OpenTestDatabase. Requests issued after closing the client fail with ErrClientClosed, closing an already
+// closed client returns ErrClientClosed as well.
+func (c *Client) CloseContext(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrClientClosed
+	}
+
+	c.closed = true
+	c.client.CloseIdleConnections()
+
+	return c.dbs.close()
+}
+
+func (c *Client) ResetAllTracking(ctx context.Context) error {
+	ctx = withOperation(ctx, Operation{Name: "ResetAllTracking"})
+
+	req, err := c.newRequest(ctx, "DELETE", "/admin/templates", nil)
+	if err != nil {
+		return fmt.Errorf("reset all tracking: %w", err)
+	}
+
+	resp, err := c.do(req, nil)
+	if err != nil {
+		return fmt.Errorf("reset all tracking: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil
+	case http.StatusServiceUnavailable:
+		return fmt.Errorf("reset all tracking: %w", newNotReadyError(resp))
+	default:
+		return fmt.Errorf("reset all tracking: %w", newAPIError(resp))
+	}
+}
+
+// ListTemplates lists all templates currently tracked by the manager via GET /admin/templates.
+// Note that this endpoint is not supported by all versions of IntegreSQL.
+func (c *Client) ListTemplates(ctx context.Context) ([]models.TemplateDatabase, error) {
+	var templates []models.TemplateDatabase
+
+	ctx = withOperation(ctx, Operation{Name: "ListTemplates"})
+
+	req, err := c.newRequest(ctx, "GET", "/admin/templates", nil)
+	if err != nil {
+		return nil, fmt.Errorf("list templates: %w", err)
+	}
+
+	resp, err := c.do(req, &templates)
+	if err != nil {
+		return nil, fmt.Errorf("list templates: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		for i := range templates {
+			templates[i].Config = c.rewriteDatabaseConfig(ctx, templates[i].Config)
+		}
+
+		return templates, nil
+	case http.StatusServiceUnavailable:
+		return nil, fmt.Errorf("list templates: %w", newNotReadyError(resp))
+	default:
+		return nil, fmt.Errorf("list templates: %w", newAPIError(resp))
+	}
+}
+
+// IsReady queries the healthcheck endpoint of the manager, returning true if it is ready to accept requests.
+func (c *Client) IsReady(ctx context.Context) (bool, error) {
+	ctx = withOperation(ctx, Operation{Name: "IsReady"})
+
+	req, err := c.newRequest(ctx, "GET", "/admin/healthz", nil)
+	if err != nil {
+		return false, fmt.Errorf("query manager health: %w", err)
+	}
+
+	resp, err := c.do(req, nil)
+	if err != nil {
+		return false, fmt.Errorf("query manager health: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusServiceUnavailable:
+		return false, nil
+	default:
+		return false, fmt.Errorf("query manager health: %w", newAPIError(resp))
+	}
+}
+
+// healthResponseBody receives the raw body of a successful healthcheck response from send, as managers not reporting
+// any details respond with an empty body which cannot be decoded as JSON.
+type healthResponseBody []byte
+
+// Health queries the healthcheck endpoint of the manager, returning its reported version, readiness and pool summary.
+// Both healthy (200) and not ready (503) responses are decoded, the latter returning a HealthStatus with Ready unset
+// instead of an error. Managers not reporting any details respond with an empty body, in which case only Ready is set.
+func (c *Client) Health(ctx context.Context) (models.HealthStatus, error) {
+	ctx = withOperation(ctx, Operation{Name: "Health"})
+
+	var status models.HealthStatus
+
+	req, err := c.newRequest(ctx, "GET", "/admin/healthz", nil)
+	if err != nil {
+		return status, fmt.Errorf("query manager health: %w", err)
+	}
+
+	var body healthResponseBody
+	resp, err := c.do(req, &body)
+	if err != nil {
+		return status, fmt.Errorf("query manager health: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusServiceUnavailable:
+		// unsuccessful responses are buffered by send instead of being passed as body
+		if body, err = ioutil.ReadAll(resp.Body); err != nil {
+			return status, fmt.Errorf("query manager health: %w", err)
+		}
+	default:
+		return status, fmt.Errorf("query manager health: %w", newAPIError(resp))
+	}
+
+	if len(bytes.TrimSpace(body)) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(body))
+		if c.config.StrictJSON {
+			dec.DisallowUnknownFields()
+		}
+
+		if err := dec.Decode(&status); err != nil {
+			return status, fmt.Errorf("query manager health: %w", err)
+		}
+	}
+
+	// the status code is authoritative, matching IsReady
+	status.Ready = resp.StatusCode == http.StatusOK
+
+	return status, nil
+}
+
+// WaitForReady polls the healthcheck endpoint of the manager every interval until it reports to be ready.
+// Failing connection attempts are retried, as the manager might still be booting. WaitForReady aborts early
+// if the manager responds with an unexpected HTTP status and returns the context's error (wrapped) once it is done.
+func (c *Client) WaitForReady(ctx context.Context, interval time.Duration) error {
+	for {
+		ready, err := c.IsReady(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("failed to wait for manager to become ready: %w", ctx.Err())
+			}
+
+			// connection errors are retried as the manager might still be booting, unexpected responses are not
+			var urlErr *url.Error
+			if !errors.As(err, &urlErr) {
+				return err
+			}
+		} else if ready {
+			return nil
+		}
+
+		if err := sleepContext(ctx, interval); err != nil {
+			return fmt.Errorf("failed to wait for manager to become ready: %w", err)
+		}
+	}
+}
+
+// NegotiateAPIVersion determines the newest API version supported by both the client and the manager by probing
+// the healthcheck endpoint of each API version known to the client (see APIVersionV1), returning
+// ErrAPIVersionNotSupported if the manager responded with 404 Not Found for all of them. The client itself
+// keeps using its configured APIVersion, create a new client to switch to the negotiated version.
+func (c *Client) NegotiateAPIVersion(ctx context.Context) (string, error) {
+	ctx = withOperation(ctx, Operation{Name: "NegotiateAPIVersion"})
+
+	for _, version := range supportedAPIVersions {
+		base := c.rootURL.ResolveReference(&url.URL{Path: path.Join(c.rootURL.Path, version)})
+
+		req, err := c.newRequestWithBaseURL(ctx, base, "GET", "/admin/healthz", nil)
+		if err != nil {
+			return "", fmt.Errorf("negotiate API version: %w", err)
+		}
+
+		resp, err := c.do(req, nil)
+		if err != nil {
+			return "", fmt.Errorf("negotiate API version: %w", err)
+		}
+
+		switch resp.StatusCode {
+		case http.StatusOK, http.StatusServiceUnavailable:
+			// the manager might not be ready yet, but provides the endpoint nevertheless
+			return version, nil
+		case http.StatusNotFound:
+			continue
+		default:
+			return "", fmt.Errorf("negotiate API version: %w", newAPIError(resp))
+		}
+	}
+
+	return "", fmt.Errorf("negotiate API version: %w", ErrAPIVersionNotSupported)
+}
+
+func (c *Client) InitializeTemplate(ctx context.Context, hash string) (models.TemplateDatabase, error) {
+	template, _, err := c.InitializeTemplateResponse(ctx, hash)
+	return template, err
+}
+
+// InitializeTemplateResponse works like InitializeTemplate, but additionally returns the manager's response
+// (if any), allowing to inspect its status and headers. The response body has already been consumed and closed.
+func (c *Client) InitializeTemplateResponse(ctx context.Context, hash string) (models.TemplateDatabase, *http.Response, error) {
+	var template models.TemplateDatabase
+
+	payload := map[string]string{"hash": hash}
+
+	ctx = withOperation(ctx, Operation{Name: "InitializeTemplate", Hash: hash})
+
+	req, err := c.newRequest(ctx, "POST", "/templates", payload)
+	if err != nil {
+		return template, nil, fmt.Errorf("initialize template %q: %w", hash, err)
+	}
+
+	resp, err := c.do(req, &template)
+	if err != nil {
+		return template, nil, fmt.Errorf("initialize template %q: %w", hash, err)
+	}
+
+	defer func() {
+		resp.Body = http.NoBody
+	}()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		template.Config = c.rewriteDatabaseConfig(ctx, template.Config)
+
+		return template, resp, nil
+	case http.StatusLocked:
+		return template, resp, fmt.Errorf("initialize template %q: %w", hash, ErrTemplateAlreadyInitialized)
+	case http.StatusServiceUnavailable:
+		return template, resp, fmt.Errorf("initialize template %q: %w", hash, newNotReadyError(resp))
+	default:
+		return template, resp, fmt.Errorf("initialize template %q: %w", hash, newAPIError(resp))
+	}
+}
+
+// GetTemplate retrieves the template identified by hash via GET /templates/{hash} without (re)initializing it.
+// Note that this endpoint is not supported by all versions of IntegreSQL.
+func (c *Client) GetTemplate(ctx context.Context, hash string) (models.TemplateDatabase, error) {
+	var template models.TemplateDatabase
+
+	ctx = withOperation(ctx, Operation{Name: "GetTemplate", Hash: hash})
+
+	req, err := c.newRequest(ctx, "GET", fmt.Sprintf("/templates/%s", hash), nil)
+	if err != nil {
+		return template, fmt.Errorf("get template %q: %w", hash, err)
+	}
+
+	resp, err := c.do(req, &template)
+	if err != nil {
+		return template, fmt.Errorf("get template %q: %w", hash, err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		template.Config = c.rewriteDatabaseConfig(ctx, template.Config)
+
+		return template, nil
+	case http.StatusNotFound:
+		return template, fmt.Errorf("get template %q: %w", hash, ErrTemplateNotFound)
+	case http.StatusServiceUnavailable:
+		return template, fmt.Errorf("get template %q: %w", hash, newNotReadyError(resp))
+	default:
+		return template, fmt.Errorf("get template %q: %w", hash, newAPIError(resp))
+	}
+}
+
+// TemplateStats retrieves the pool statistics of the template identified by hash, e.g. to detect a pool undersized
+// for the parallelism of the tests. Note that this endpoint is not supported by all versions of IntegreSQL.
+func (c *Client) TemplateStats(ctx context.Context, hash string) (models.TemplatePoolStats, error) {
+	var stats models.TemplatePoolStats
+
+	ctx = withOperation(ctx, Operation{Name: "TemplateStats", Hash: hash})
+
+	req, err := c.newRequest(ctx, "GET", fmt.Sprintf("/templates/%s/stats", hash), nil)
+	if err != nil {
+		return stats, fmt.Errorf("get stats of template %q: %w", hash, err)
+	}
+
+	resp, err := c.do(req, &stats)
+	if err != nil {
+		return stats, fmt.Errorf("get stats of template %q: %w", hash, err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return stats, nil
+	case http.StatusNotFound:
+		return stats, fmt.Errorf("get stats of template %q: %w", hash, ErrTemplateNotFound)
+	case http.StatusServiceUnavailable:
+		return stats, fmt.Errorf("get stats of template %q: %w", hash, newNotReadyError(resp))
+	default:
+		return stats, fmt.Errorf("get stats of template %q: %w", hash, newAPIError(resp))
+	}
+}
+
+// TemplateExists reports whether the manager tracks a template identified by hash via GetTemplate, without
+// initializing, finalizing or discarding it. Note that this endpoint is not supported by all versions of IntegreSQL.
+func (c *Client) TemplateExists(ctx context.Context, hash string) (bool, error) {
+	if _, err := c.GetTemplate(ctx, hash); err != nil {
+		if errors.Is(err, ErrTemplateNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// VerifyTemplateHash records fingerprint (e.g. a checksum of the schema) for the template identified by hash on
+// its first call for hash and compares it to the recorded one on subsequent calls, returning ErrTemplateHashCollision
+// if they differ. This allows detecting two different sets of migrations accidentally resulting in the same hash.
+// Fingerprints are only kept in memory by the client, the manager is not contacted.
+func (c *Client) VerifyTemplateHash(ctx context.Context, hash string, fingerprint string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.fingerprints == nil {
+		c.fingerprints = make(map[string]string)
+	}
+
+	recorded, ok := c.fingerprints[hash]
+	if !ok {
+		c.fingerprints[hash] = fingerprint
+		return nil
+	}
+
+	if recorded != fingerprint {
+		return fmt.Errorf("verify template %q: %w (recorded %q, got %q)", hash, ErrTemplateHashCollision, recorded, fingerprint)
+	}
+
+	return nil
+}
+
+// SetupTemplate initializes the template identified by hash, passes the connection string of the template database
+// to init and finalizes the template afterwards. Already initialized templates are skipped. Errors returned by init
+// and errors finalizing the template (after init succeeded) are wrapped with distinct messages, allowing to tell
+// whether the template database was set up successfully. If DiscardTemplateOnInitError is enabled, the template is
+// discarded if init fails, so the next run does not find a half-baked template.
+func (c *Client) SetupTemplate(ctx context.Context, hash string, init func(conn string) error) error {
+	_, err := c.SetupTemplateStatus(ctx, hash, init)
+	return err
+}
+
+// SetupTemplateStatus works like SetupTemplate, but additionally reports whether the template was created by this
+// call (init ran), or whether it was already initialized and has been reused, e.g. to log which of both happened.
+// created is true if init succeeded, even if finalizing the template failed afterwards.
+func (c *Client) SetupTemplateStatus(ctx context.Context, hash string, init func(conn string) error) (created bool, err error) {
+	template, err := c.InitializeTemplate(ctx, hash)
+	if err == nil {
+		if err := init(template.Config.ConnectionString()); err != nil {
+			return false, c.abortTemplateSetup(ctx, hash, fmt.Errorf("init of template %q failed: %w", hash, err))
+		}
+
+		return true, c.finalizeInitializedTemplate(ctx, hash)
+	} else if errors.Is(err, ErrTemplateAlreadyInitialized) {
+		return false, nil
+	} else {
+		return false, err
+	}
+}
+
+// SetupTemplateTimeout works like SetupTemplate, but limits the whole sequence of initializing the template, running
+// init and finalizing the template to timeout, e.g. to enforce a hard cap on template build time in CI. init receives
+// the derived context and must respect its cancellation for the timeout to interrupt it. The template is discarded
+// using ctx (if DiscardTemplateOnInitError is enabled), so an overrunning init does not prevent the cleanup.
+func (c *Client) SetupTemplateTimeout(ctx context.Context, hash string, timeout time.Duration, init func(ctx context.Context, conn string) error) error {
+	setupCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	template, err := c.InitializeTemplate(setupCtx, hash)
+	if err == nil {
+		if err := init(setupCtx, template.Config.ConnectionString()); err != nil {
+			return c.abortTemplateSetup(ctx, hash, fmt.Errorf("init of template %q failed: %w", hash, err))
+		}
+
+		return c.finalizeInitializedTemplate(setupCtx, hash)
+	} else if errors.Is(err, ErrTemplateAlreadyInitialized) {
+		return nil
+	} else {
+		return err
+	}
+}
+
+// DBOptions configures the connection pool opened by SetupTemplateWithDBClientOpts before it is passed to init.
+// Zero values keep the defaults of database/sql.
+type DBOptions struct {
+	MaxOpenConns    int           // see sql.DB.SetMaxOpenConns, e.g. 1 to run migrations on a single connection
+	MaxIdleConns    int           // see sql.DB.SetMaxIdleConns
+	ConnMaxLifetime time.Duration // see sql.DB.SetConnMaxLifetime
+}
+
+// apply configures db according to the options set.
+func (o DBOptions) apply(db *sql.DB) {
+	if o.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(o.MaxOpenConns)
+	}
+
+	if o.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(o.MaxIdleConns)
+	}
+
+	if o.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(o.ConnMaxLifetime)
+	}
+}
+
+// SetupTemplateWithDBClient works like SetupTemplate, but passes an open connection to the template database to init.
+func (c *Client) SetupTemplateWithDBClient(ctx context.Context, hash string, init func(db *sql.DB) error) error {
+	return c.SetupTemplateWithDBClientOpts(ctx, hash, DBOptions{}, init)
+}
+
+// SetupTemplateWithDBClientOpts works like SetupTemplateWithDBClient, but configures the connection pool
+// according to opts before pinging it and passing it to init.
+func (c *Client) SetupTemplateWithDBClientOpts(ctx context.Context, hash string, opts DBOptions, init func(db *sql.DB) error) error {
+	var db *sql.DB
+
+	connect := func(ctx context.Context, config models.DatabaseConfig) (func(ctx context.Context) error, func(), error) {
+		var err error
+		db, err = sql.Open(c.config.DriverName, config.ConnectionString())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		opts.apply(db)
+
+		return db.PingContext, func() { db.Close() }, nil
+	}
+
+	return c.SetupTemplateWithConnection(ctx, hash, connect, func(ctx context.Context) error {
+		return init(db)
+	})
+}
+
+// TemplateConnectFunc connects to the template database described by config for SetupTemplateWithConnection,
+// returning a func pinging the connection and a func closing it.
+type TemplateConnectFunc func(ctx context.Context, config models.DatabaseConfig) (ping func(ctx context.Context) error, close func(), err error)
+
+// SetupTemplateWithConnection initializes the template identified by hash, connects to the template database via
+// connect and waits for it to accept connections by pinging it (retried according to PingRetries and PingBackoff).
+// init is called afterwards and the template finalized once it succeeded, the connection is closed before returning.
+// Already initialized templates are skipped. If DiscardTemplateOnInitError is enabled, the template is discarded
+// if connecting, pinging or init fails. This is the building block of SetupTemplateWithDBClient and the driver
+// specific variants in the subpackages, e.g. integresqlpgx.
+func (c *Client) SetupTemplateWithConnection(ctx context.Context, hash string, connect TemplateConnectFunc, init func(ctx context.Context) error) error {
+	template, err := c.InitializeTemplate(ctx, hash)
+	if err == nil {
+		ping, closeConn, err := connect(ctx, template.Config)
+		if err != nil {
+			return c.abortTemplateSetup(ctx, hash, fmt.Errorf("failed to connect to template %q: %w", hash, err))
+		}
+		defer closeConn()
+
+		if err := c.pingWithRetry(ctx, ping); err != nil {
+			return c.abortTemplateSetup(ctx, hash, fmt.Errorf("failed to connect to template %q: %w", hash, err))
+		}
+
+		if err := init(ctx); err != nil {
+			return c.abortTemplateSetup(ctx, hash, fmt.Errorf("init of template %q failed: %w", hash, err))
+		}
+
+		return c.finalizeInitializedTemplate(ctx, hash)
+	} else if errors.Is(err, ErrTemplateAlreadyInitialized) {
+		return nil
+	} else {
+		return err
+	}
+}
+
+// pingDB pings the freshly opened db, retrying up to PingRetries times with a bounded exponential backoff, as
+// Postgres might not accept connections to a database yet right after the manager handed it out.
+func (c *Client) pingDB(ctx context.Context, db *sql.DB) error {
+	return c.pingWithRetry(ctx, db.PingContext)
+}
+
+// pingWithRetry calls ping until it succeeds, retrying up to PingRetries times with a bounded exponential backoff.
+func (c *Client) pingWithRetry(ctx context.Context, ping func(ctx context.Context) error) error {
+	backoff := c.config.PingBackoff
+
+	for attempt := 0; ; attempt++ {
+		err := ping(ctx)
+		if err == nil || attempt >= c.config.PingRetries || ctx.Err() != nil {
+			return err
+		}
+
+		if err := sleepContext(ctx, backoff); err != nil {
+			return err
+		}
+
+		backoff *= 2
+		if backoff > maxPingBackoff {
+			backoff = maxPingBackoff
+		}
+	}
+}
+
+// abortTemplateSetup discards the template identified by hash if DiscardTemplateOnInitError is enabled, as its
+// init failed with err. Errors discarding the template are returned alongside err.
+func (c *Client) abortTemplateSetup(ctx context.Context, hash string, err error) error {
+	if !c.config.DiscardTemplateOnInitError {
+		return err
+	}
+
+	if discardErr := c.DiscardTemplate(ctx, hash); discardErr != nil {
+		return errors.Join(err, discardErr)
+	}
+
+	return err
+}
+
+// finalizeInitializedTemplate finalizes the template identified by hash after its init succeeded, making clear
+// in the returned error that only finalizing the template failed.
+func (c *Client) finalizeInitializedTemplate(ctx context.Context, hash string) error {
+	if err := c.FinalizeTemplate(ctx, hash); err != nil {
+		return fmt.Errorf("template %q initialized but finalize failed: %w", hash, err)
+	}
+
+	return nil
+}
+
+// SetupTemplateFromFS sets up the template identified by hash via SetupTemplateWithDBClient, executing all .sql
+// files found in dir of fsys (e.g. an embed.FS) in order of their file names. Each file is executed as a whole
+// via a single db.ExecContext call, subdirectories are ignored.
+func (c *Client) SetupTemplateFromFS(ctx context.Context, hash string, fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+
+	return c.SetupTemplateWithDBClient(ctx, hash, func(db *sql.DB) error {
+		for _, entry := range entries {
+			if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+				continue
+			}
+
+			file := path.Join(dir, entry.Name())
+
+			query, err := fs.ReadFile(fsys, file)
+			if err != nil {
+				return err
+			}
+
+			if _, err := db.ExecContext(ctx, string(query)); err != nil {
+				return fmt.Errorf("failed to execute %q: %w", file, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (c *Client) DiscardTemplate(ctx context.Context, hash string) error {
+	ctx = withOperation(ctx, Operation{Name: "DiscardTemplate", Hash: hash})
+
+	req, err := c.newRequest(ctx, "DELETE", fmt.Sprintf("/templates/%s", hash), nil)
+	if err != nil {
+		return fmt.Errorf("discard template %q: %w", hash, err)
+	}
+
+	resp, err := c.do(req, nil)
+	if err != nil {
+		return fmt.Errorf("discard template %q: %w", hash, err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("discard template %q: %w", hash, ErrTemplateNotFound)
+	case http.StatusServiceUnavailable:
+		return fmt.Errorf("discard template %q: %w", hash, newNotReadyError(resp))
+	default:
+		return fmt.Errorf("discard template %q: %w", hash, newAPIError(resp))
+	}
+}
+
+// FinalizeTemplate finalizes the template identified by hash, allowing test databases to be created from it.
+// Finalizing an already finalized template (e.g. by another worker setting up the same template concurrently)
+// is considered successful, the manager responds with 409 Conflict or 423 Locked in this case.
+func (c *Client) FinalizeTemplate(ctx context.Context, hash string) error {
+	ctx = withOperation(ctx, Operation{Name: "FinalizeTemplate", Hash: hash})
+
+	req, err := c.newRequest(ctx, "PUT", fmt.Sprintf("/templates/%s", hash), nil)
+	if err != nil {
+		return fmt.Errorf("finalize template %q: %w", hash, err)
+	}
+
+	resp, err := c.do(req, nil)
+	if err != nil {
+		return fmt.Errorf("finalize template %q: %w", hash, err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNoContent, http.StatusConflict, http.StatusLocked:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("finalize template %q: %w", hash, ErrTemplateNotFound)
+	case http.StatusServiceUnavailable:
+		return fmt.Errorf("finalize template %q: %w", hash, newNotReadyError(resp))
+	default:
+		return fmt.Errorf("finalize template %q: %w", hash, newAPIError(resp))
+	}
+}
+
+// GetTestDatabase retrieves a test database for the template identified by hash. If the manager responds with
+// 429 Too Many Requests as its pool of test databases is temporarily exhausted, the request is retried up to
+// GetTestDatabaseRetries times with a bounded exponential backoff. Other statuses (e.g. 404 Not Found for an
+// unknown template) are never retried by GetTestDatabase itself.
+//
+// Statuses are mapped to errors as follows, allowing callers to tell backpressure apart from fatal conditions:
+// 404 Not Found to ErrTemplateNotFound, 410 Gone to ErrDatabaseDiscarded, 429 Too Many Requests (once all retries
+// have been used up) to ErrPoolExhausted, which is worth waiting for, and 503 Service Unavailable to
+// ErrManagerNotReady.
+func (c *Client) GetTestDatabase(ctx context.Context, hash string) (models.TestDatabase, error) {
+	test, _, err := c.GetTestDatabaseResponse(ctx, hash)
+	return test, err
+}
+
+// WaitForTestDatabase works like GetTestDatabase, but blocks until a test database becomes available if the pool
+// of the template is exhausted (ErrPoolExhausted), retrying with a bounded exponential backoff starting at
+// GetTestDatabaseBackoff. This allows running more tests in parallel than the pool has test databases. Other errors
+// are returned right away, the context's error (wrapped) is returned once it is done.
+func (c *Client) WaitForTestDatabase(ctx context.Context, hash string) (models.TestDatabase, error) {
+	backoff := c.config.GetTestDatabaseBackoff
+
+	for {
+		test, err := c.GetTestDatabase(ctx, hash)
+		if err == nil {
+			return test, nil
+		}
+
+		if ctx.Err() != nil {
+			return test, fmt.Errorf("failed to wait for test database of template %q: %w", hash, ctx.Err())
+		}
+
+		if !errors.Is(err, ErrPoolExhausted) {
+			return test, err
+		}
+
+		if err := sleepContext(ctx, backoff); err != nil {
+			return test, fmt.Errorf("failed to wait for test database of template %q: %w", hash, err)
+		}
+
+		backoff *= 2
+		if backoff > maxGetTestDatabaseBackoff {
+			backoff = maxGetTestDatabaseBackoff
+		}
+	}
+}
+
+// testLabelHeader is the header carrying the label passed to GetTestDatabaseLabeled.
+const testLabelHeader = "X-Test-Label"
+
+type testLabelContextKey struct{}
+
+// GetTestDatabaseLabeled works like GetTestDatabase, but additionally sends label (e.g. the name of the test) via
+// the X-Test-Label header, allowing managers supporting it to show which test holds which test database, e.g. to
+// diagnose leaked test databases. Managers not supporting labels ignore the header.
+func (c *Client) GetTestDatabaseLabeled(ctx context.Context, hash string, label string) (models.TestDatabase, error) {
+	return c.GetTestDatabase(context.WithValue(ctx, testLabelContextKey{}, label), hash)
+}
+
+// GetTestDatabaseResponse works like GetTestDatabase, but additionally returns the manager's (last) response
+// (if any), allowing to inspect its status and headers. The response body has already been consumed and closed.
+func (c *Client) GetTestDatabaseResponse(ctx context.Context, hash string) (models.TestDatabase, *http.Response, error) {
+	var test models.TestDatabase
 
-	"github.com/allaboutapps/integresql-client-go/pkg/models"
-)
+	ctx = withOperation(ctx, Operation{Name: "GetTestDatabase", Hash: hash})
 
-var (
-	ErrManagerNotReady            = errors.New("manager not ready")
-	ErrTemplateAlreadyInitialized = errors.New("template is already initialized")
-	ErrTemplateNotFound           = errors.New("template not found")
-	ErrDatabaseDiscarded          = errors.New("database was discarded (typically failed during initialize/finalize)")
-	ErrTestNotFound               = errors.New("test database not found")
-)
+	var resp *http.Response
+	backoff := c.config.GetTestDatabaseBackoff
 
-type Client struct {
-	baseURL *url.URL
-	client  *http.Client
-	config  ClientConfig
-}
+	for attempt := 0; ; attempt++ {
+		attemptCtx := withOperation(ctx, Operation{Name: "GetTestDatabase", Hash: hash, Attempt: attempt + 1})
 
-func NewClient(config ClientConfig) (*Client, error) {
-	c := &Client{
-		baseURL: nil,
-		client:  nil,
-		config:  config,
+		req, err := c.newRequest(attemptCtx, "GET", fmt.Sprintf("/templates/%s/tests", hash), nil)
+		if err != nil {
+			return test, nil, fmt.Errorf("get test database for template %q: %w", hash, err)
+		}
+
+		if label, ok := ctx.Value(testLabelContextKey{}).(string); ok && len(label) > 0 {
+			req.Header.Set(testLabelHeader, label)
+		}
+
+		resp, err = c.do(req, &test)
+		if err != nil {
+			return test, nil, fmt.Errorf("get test database for template %q: %w", hash, err)
+		}
+
+		// the pool of test databases is temporarily exhausted, retry once a test database might have become available
+		if resp.StatusCode != http.StatusTooManyRequests || attempt >= c.config.GetTestDatabaseRetries {
+			break
+		}
+
+		if err := sleepContext(ctx, backoff); err != nil {
+			return test, nil, fmt.Errorf("get test database for template %q: %w", hash, err)
+		}
+
+		backoff *= 2
+		if backoff > maxGetTestDatabaseBackoff {
+			backoff = maxGetTestDatabaseBackoff
+		}
 	}
 
-	defaultConfig := DefaultClientConfigFromEnv()
+	defer func() {
+		resp.Body = http.NoBody
+	}()
 
-	if len(c.config.BaseURL) == 0 {
-		c.config.BaseURL = defaultConfig.BaseURL
+	switch resp.StatusCode {
+	case http.StatusOK:
+		test.Config = c.rewriteDatabaseConfig(ctx, test.Config)
+
+		return test, resp, nil
+	case http.StatusNotFound:
+		return test, resp, fmt.Errorf("get test database for template %q: %w", hash, ErrTemplateNotFound)
+	case http.StatusGone:
+		return test, resp, fmt.Errorf("get test database for template %q: %w", hash, ErrDatabaseDiscarded)
+	case http.StatusTooManyRequests:
+		return test, resp, fmt.Errorf("get test database for template %q: %w", hash, ErrPoolExhausted)
+	case http.StatusServiceUnavailable:
+		return test, resp, fmt.Errorf("get test database for template %q: %w", hash, newNotReadyError(resp))
+	default:
+		return test, resp, fmt.Errorf("get test database for template %q: %w", hash, newAPIError(resp))
 	}
+}
 
-	if len(c.config.APIVersion) == 0 {
-		c.config.APIVersion = defaultConfig.APIVersion
+// ResetTestDatabases resets all test databases of the template identified by hash via DELETE /templates/{hash}/tests,
+// e.g. after a schema hotfix, without discarding the template itself. Note that this endpoint is not supported by all
+// versions of IntegreSQL.
+func (c *Client) ResetTestDatabases(ctx context.Context, hash string) error {
+	ctx = withOperation(ctx, Operation{Name: "ResetTestDatabases", Hash: hash})
+
+	req, err := c.newRequest(ctx, "DELETE", fmt.Sprintf("/templates/%s/tests", hash), nil)
+	if err != nil {
+		return fmt.Errorf("reset test databases of template %q: %w", hash, err)
 	}
 
-	u, err := url.Parse(c.config.BaseURL)
+	resp, err := c.do(req, nil)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("reset test databases of template %q: %w", hash, err)
 	}
 
-	c.baseURL = u.ResolveReference(&url.URL{Path: path.Join(u.Path, c.config.APIVersion)})
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("reset test databases of template %q: %w", hash, ErrTemplateNotFound)
+	case http.StatusServiceUnavailable:
+		return fmt.Errorf("reset test databases of template %q: %w", hash, newNotReadyError(resp))
+	default:
+		return fmt.Errorf("reset test databases of template %q: %w", hash, newAPIError(resp))
+	}
+}
 
-	c.client = &http.Client{}
+// ResetTemplate rebuilds the test database pool of the finalized template identified by hash via
+// POST /templates/{hash}/reset, invalidating all outstanding test databases and recreating them from the template,
+// e.g. after an out-of-band schema change during development. Note that this endpoint is not supported by all
+// versions of IntegreSQL.
+func (c *Client) ResetTemplate(ctx context.Context, hash string) error {
+	ctx = withOperation(ctx, Operation{Name: "ResetTemplate", Hash: hash})
 
-	return c, nil
+	req, err := c.newRequest(ctx, "POST", fmt.Sprintf("/templates/%s/reset", hash), nil)
+	if err != nil {
+		return fmt.Errorf("reset template %q: %w", hash, err)
+	}
+
+	resp, err := c.do(req, nil)
+	if err != nil {
+		return fmt.Errorf("reset template %q: %w", hash, err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusAccepted, http.StatusNoContent:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("reset template %q: %w", hash, ErrTemplateNotFound)
+	case http.StatusGone:
+		return fmt.Errorf("reset template %q: %w", hash, ErrDatabaseDiscarded)
+	case http.StatusServiceUnavailable:
+		return fmt.Errorf("reset template %q: %w", hash, newNotReadyError(resp))
+	default:
+		return fmt.Errorf("reset template %q: %w", hash, newAPIError(resp))
+	}
 }
 
-func DefaultClientFromEnv() (*Client, error) {
-	return NewClient(DefaultClientConfigFromEnv())
+// ReturnTestDatabase returns the test database with the given ID to the pool of the template identified by hash
+// by issuing DELETE /templates/{hash}/tests/{id}. This endpoint is supported by IntegreSQL v1.0.x and
+// deprecated on newer servers (v1.1.0 and above), use RecreateTestDatabase instead when targeting those.
+// The test database is handed out again as is, so only use this for test databases which were never modified, use
+// ReleaseTestDatabase to give back test databases modified by a test.
+func (c *Client) ReturnTestDatabase(ctx context.Context, hash string, id models.TestDatabaseID) error {
+	ctx = withOperation(ctx, Operation{Name: "ReturnTestDatabase", Hash: hash, TestID: id})
+
+	return c.deleteTestDatabase(ctx, "return", hash, id)
 }
 
-func (c *Client) SetClient(client *http.Client) {
-	c.client = client
+// ReleaseTestDatabase gives the test database with the given ID, which might have been modified by a test, back to
+// the pool of the template identified by hash. It is recreated from its template via RecreateTestDatabase, unless
+// UnlockOnRelease is enabled for IntegreSQL v1.0.x servers not supporting this, in which case it is returned via
+// ReturnTestDatabase. This is used by all helpers handing out test databases to tests, e.g. WithTestDatabase.
+func (c *Client) ReleaseTestDatabase(ctx context.Context, hash string, id models.TestDatabaseID) error {
+	if c.config.UnlockOnRelease {
+		return c.ReturnTestDatabase(ctx, hash, id)
+	}
+
+	return c.RecreateTestDatabase(ctx, hash, id)
 }
 
-func (c *Client) Close() {
-	c.client.CloseIdleConnections()
+// RecycleTestDatabase recreates the test database with the given ID via RecreateTestDatabase, returning it to the pool
+// of the template identified by hash, and retrieves a new one in its place, e.g. to get a fresh test database for
+// every iteration of a test loop. If retrieving the new test database fails, the old one has still been returned
+// and must not be used anymore. This requires IntegreSQL v1.1.0 or above.
+func (c *Client) RecycleTestDatabase(ctx context.Context, hash string, id models.TestDatabaseID) (models.TestDatabase, error) {
+	if err := c.RecreateTestDatabase(ctx, hash, id); err != nil {
+		return models.TestDatabase{}, fmt.Errorf("recycle test database %d of template %q: %w", id, hash, err)
+	}
+
+	test, err := c.GetTestDatabase(ctx, hash)
+	if err != nil {
+		return test, fmt.Errorf("recycle test database %d of template %q: test database has already been returned: %w", id, hash, err)
+	}
+
+	return test, nil
 }
 
-func (c *Client) ResetAllTracking(ctx context.Context) error {
-	req, err := c.newRequest(ctx, "DELETE", "/admin/templates", nil)
+// WithTestDatabase retrieves a test database for the template identified by hash, opens and pings a connection to it
+// and passes it to fn. The test database is always released via ReleaseTestDatabase afterwards, even if fn fails or
+// panics. An error returned by fn takes precedence over an error releasing the test database.
+func (c *Client) WithTestDatabase(ctx context.Context, hash string, fn func(db *sql.DB) error) (err error) {
+	test, err := c.GetTestDatabase(ctx, hash)
 	if err != nil {
 		return err
 	}
 
-	var msg string
-	resp, err := c.do(req, &msg)
+	defer func() {
+		if releaseErr := c.ReleaseTestDatabase(ctx, hash, test.ID); releaseErr != nil && err == nil {
+			err = releaseErr
+		}
+	}()
+
+	db, err := sql.Open(c.config.DriverName, test.Config.ConnectionString())
 	if err != nil {
 		return err
 	}
+	defer db.Close()
 
-	if resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("failed to reset all tracking: %v", msg)
+	if err := c.pingDB(ctx, db); err != nil {
+		return err
 	}
 
-	return nil
+	return fn(db)
 }
 
-func (c *Client) InitializeTemplate(ctx context.Context, hash string) (models.TemplateDatabase, error) {
-	var template models.TemplateDatabase
-
-	payload := map[string]string{"hash": hash}
-
-	req, err := c.newRequest(ctx, "POST", "/templates", payload)
+// OpenTestDatabase retrieves a test database for the template identified by hash and returns it alongside a pinged
+// connection pool to it. As IntegreSQL hands out the same test databases again once they have been returned,
+// connection pools are cached by their connection string and reused across calls instead of opening a new pool
+// for every test. The returned *sql.DB is owned by the client and must not be closed by the caller, the test
+// database itself still has to be returned as usual. A pool is never closed while its test database is in use,
+// giving the test database back to the manager (via ReturnTestDatabase, RecreateTestDatabase or
+// UnlockTestDatabase) releases it and closes its idle connections, as open connections would block the manager
+// from dropping and recreating the test database. Note that connections still checked out of the pool (e.g.
+// by an unclosed *sql.Rows or *sql.Tx) are not closed and keep blocking the manager. Up to 16 released pools
+// are kept open, the least recently used one is closed once this limit is exceeded. All pools are closed by
+// CloseContext. If opening or pinging the connection pool fails, the retrieved test database is returned
+// alongside the error, allowing the caller to return it.
+func (c *Client) OpenTestDatabase(ctx context.Context, hash string) (models.TestDatabase, *sql.DB, error) {
+	test, err := c.GetTestDatabase(ctx, hash)
 	if err != nil {
-		return template, err
+		return test, nil, err
 	}
 
-	resp, err := c.do(req, &template)
+	db, err := c.dbs.get(c.config.DriverName, test.Config.ConnectionString(), hash, test.ID)
 	if err != nil {
-		return template, err
+		return test, nil, err
 	}
 
-	switch resp.StatusCode {
-	case http.StatusOK:
-		return template, nil
-	case http.StatusLocked:
-		return template, ErrTemplateAlreadyInitialized
-	case http.StatusServiceUnavailable:
-		return template, ErrManagerNotReady
-	default:
-		return template, fmt.Errorf("received unexpected HTTP status %d (%s)", resp.StatusCode, resp.Status)
+	if err := c.pingDB(ctx, db); err != nil {
+		return test, nil, err
 	}
-}
 
-func (c *Client) SetupTemplate(ctx context.Context, hash string, init func(conn string) error) error {
-	template, err := c.InitializeTemplate(ctx, hash)
-	if err == nil {
-		if err := init(template.Config.ConnectionString()); err != nil {
-			return err
-		}
+	return test, db, nil
+}
 
-		return c.FinalizeTemplate(ctx, hash)
-	} else if err == ErrTemplateAlreadyInitialized {
-		return nil
-	} else {
+// Ping verifies the manager is ready to hand out test databases for the template identified by hash and that they
+// are reachable, by retrieving a test database, connecting to and pinging it. The test database is always returned
+// to the pool afterwards, even if connecting to it failed. This is meant as a preflight check before a test suite.
+func (c *Client) Ping(ctx context.Context, hash string) (err error) {
+	test, err := c.GetTestDatabase(ctx, hash)
+	if err != nil {
 		return err
 	}
-}
-
-func (c *Client) SetupTemplateWithDBClient(ctx context.Context, hash string, init func(db *sql.DB) error) error {
-	template, err := c.InitializeTemplate(ctx, hash)
-	if err == nil {
-		db, err := sql.Open("postgres", template.Config.ConnectionString())
-		if err != nil {
-			return err
-		}
-		defer db.Close()
 
-		if err := db.PingContext(ctx); err != nil {
-			return err
+	defer func() {
+		if returnErr := c.ReturnTestDatabase(ctx, hash, test.ID); returnErr != nil {
+			err = errors.Join(err, returnErr)
 		}
+	}()
 
-		if err := init(db); err != nil {
-			return err
-		}
+	db, err := sql.Open(c.config.DriverName, test.Config.ConnectionString())
+	if err != nil {
+		return fmt.Errorf("failed to connect to test database %d of template %q: %w", test.ID, hash, err)
+	}
+	defer db.Close()
 
-		return c.FinalizeTemplate(ctx, hash)
-	} else if err == ErrTemplateAlreadyInitialized {
-		return nil
-	} else {
-		return err
+	if err := c.pingDB(ctx, db); err != nil {
+		return fmt.Errorf("failed to connect to test database %d of template %q: %w", test.ID, hash, err)
 	}
+
+	return nil
 }
 
-func (c *Client) DiscardTemplate(ctx context.Context, hash string) error {
-	req, err := c.newRequest(ctx, "DELETE", fmt.Sprintf("/templates/%s", hash), nil)
+// UnlockTestDatabase gives the test database with the given ID back to the pool of the template identified by hash
+// without recreating it, by issuing DELETE /templates/{hash}/tests/{id}. Only use this for test databases which
+// were never modified (e.g. because the test was skipped), as the next test will receive the database as is.
+func (c *Client) UnlockTestDatabase(ctx context.Context, hash string, id models.TestDatabaseID) error {
+	ctx = withOperation(ctx, Operation{Name: "UnlockTestDatabase", Hash: hash, TestID: id})
+
+	return c.deleteTestDatabase(ctx, "unlock", hash, id)
+}
+
+// deleteTestDatabase gives the test database with the given ID back to the pool of the template identified by hash
+// without recreating it by issuing DELETE /templates/{hash}/tests/{id}, shared by ReturnTestDatabase and
+// UnlockTestDatabase. verb describes the operation in returned errors.
+func (c *Client) deleteTestDatabase(ctx context.Context, verb string, hash string, id models.TestDatabaseID) error {
+	// the caller is done with the test database, release its cached connection pool (if any)
+	c.dbs.release(hash, id)
+
+	req, err := c.newRequest(ctx, "DELETE", fmt.Sprintf("/templates/%s/tests/%d", hash, id), nil)
 	if err != nil {
-		return err
+		return fmt.Errorf("%s test database %d of template %q: %w", verb, id, hash, err)
 	}
 
 	resp, err := c.do(req, nil)
 	if err != nil {
-		return err
+		return fmt.Errorf("%s test database %d of template %q: %w", verb, id, hash, err)
 	}
 
 	switch resp.StatusCode {
 	case http.StatusNoContent:
 		return nil
 	case http.StatusNotFound:
-		return ErrTemplateNotFound
+		return fmt.Errorf("%s test database %d of template %q: %w", verb, id, hash, ErrTemplateNotFound)
 	case http.StatusServiceUnavailable:
-		return ErrManagerNotReady
+		return fmt.Errorf("%s test database %d of template %q: %w", verb, id, hash, newNotReadyError(resp))
 	default:
-		return fmt.Errorf("received unexpected HTTP status %d (%s)", resp.StatusCode, resp.Status)
+		return fmt.Errorf("%s test database %d of template %q: %w", verb, id, hash, newAPIError(resp))
 	}
 }
 
-func (c *Client) FinalizeTemplate(ctx context.Context, hash string) error {
-	req, err := c.newRequest(ctx, "PUT", fmt.Sprintf("/templates/%s", hash), nil)
+// RecreateTestDatabase asynchronously recreates the test database with the given ID from its template and
+// returns it to the pool by issuing POST /templates/{hash}/tests/{id}/recreate. This endpoint requires
+// IntegreSQL v1.1.0 or above, use ReturnTestDatabase when targeting older servers.
+func (c *Client) RecreateTestDatabase(ctx context.Context, hash string, id models.TestDatabaseID) error {
+	ctx = withOperation(ctx, Operation{Name: "RecreateTestDatabase", Hash: hash, TestID: id})
+
+	// the caller is done with the test database, release its cached connection pool (if any)
+	c.dbs.release(hash, id)
+
+	req, err := c.newRequest(ctx, "POST", fmt.Sprintf("/templates/%s/tests/%d/recreate", hash, id), nil)
 	if err != nil {
-		return err
+		return fmt.Errorf("recreate test database %d of template %q: %w", id, hash, err)
 	}
 
 	resp, err := c.do(req, nil)
 	if err != nil {
-		return err
+		return fmt.Errorf("recreate test database %d of template %q: %w", id, hash, err)
 	}
 
 	switch resp.StatusCode {
 	case http.StatusNoContent:
 		return nil
 	case http.StatusNotFound:
-		return ErrTemplateNotFound
+		return fmt.Errorf("recreate test database %d of template %q: %w", id, hash, ErrTemplateNotFound)
+	case http.StatusGone:
+		return fmt.Errorf("recreate test database %d of template %q: %w", id, hash, ErrDatabaseDiscarded)
 	case http.StatusServiceUnavailable:
-		return ErrManagerNotReady
+		return fmt.Errorf("recreate test database %d of template %q: %w", id, hash, newNotReadyError(resp))
 	default:
-		return fmt.Errorf("received unexpected HTTP status %d (%s)", resp.StatusCode, resp.Status)
+		return fmt.Errorf("recreate test database %d of template %q: %w", id, hash, newAPIError(resp))
 	}
 }
 
-func (c *Client) GetTestDatabase(ctx context.Context, hash string) (models.TestDatabase, error) {
-	var test models.TestDatabase
+// Do sends a request with the given method to endpoint (relative to the client's base URL, e.g. /templates) as a
+// low-level escape hatch to call endpoints of the manager not wrapped by the client. If set, body is encoded as JSON
+// and the response body of successful requests is decoded into out. The status code of the response is always
+// returned if the manager responded, alongside an *APIError if the status code is not 2xx. The endpoint may carry
+// a query string (e.g. /admin/templates?verbose=1), which is sent as is.
+func (c *Client) Do(ctx context.Context, method string, endpoint string, body interface{}, out interface{}) (int, error) {
+	ctx = withOperation(ctx, Operation{Name: "Do"})
 
-	req, err := c.newRequest(ctx, "GET", fmt.Sprintf("/templates/%s/tests", hash), nil)
+	ref, err := url.Parse(endpoint)
 	if err != nil {
-		return test, err
+		return 0, fmt.Errorf("%s %s: invalid endpoint: %w", method, endpoint, err)
 	}
 
-	resp, err := c.do(req, &test)
+	req, err := c.newRequest(ctx, method, ref.Path, body)
 	if err != nil {
-		return test, err
+		return 0, fmt.Errorf("%s %s: %w", method, endpoint, err)
 	}
 
-	switch resp.StatusCode {
-	case http.StatusOK:
-		return test, nil
-	case http.StatusNotFound:
-		return test, ErrTemplateNotFound
-	case http.StatusGone:
-		return test, ErrDatabaseDiscarded
-	case http.StatusServiceUnavailable:
-		return test, ErrManagerNotReady
-	default:
-		return test, fmt.Errorf("received unexpected HTTP status %d (%s)", resp.StatusCode, resp.Status)
-	}
-}
+	req.URL.RawQuery = ref.RawQuery
 
-func (c *Client) ReturnTestDatabase(ctx context.Context, hash string, id int) error {
-	req, err := c.newRequest(ctx, "DELETE", fmt.Sprintf("/templates/%s/tests/%d", hash, id), nil)
+	resp, err := c.do(req, out)
 	if err != nil {
-		return err
+		return 0, fmt.Errorf("%s %s: %w", method, endpoint, err)
 	}
 
-	resp, err := c.do(req, nil)
-	if err != nil {
-		return err
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("%s %s: %w", method, endpoint, newAPIError(resp))
 	}
 
-	switch resp.StatusCode {
-	case http.StatusNoContent:
-		return nil
-	case http.StatusNotFound:
-		return ErrTemplateNotFound
-	case http.StatusServiceUnavailable:
-		return ErrManagerNotReady
-	default:
-		return fmt.Errorf("received unexpected HTTP status %d (%s)", resp.StatusCode, resp.Status)
-	}
+	return resp.StatusCode, nil
 }
 
 func (c *Client) newRequest(ctx context.Context, method string, endpoint string, body interface{}) (*http.Request, error) {
-	u := c.baseURL.ResolveReference(&url.URL{Path: path.Join(c.baseURL.Path, endpoint)})
+	base, err := c.baseURLFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.newRequestWithBaseURL(ctx, base, method, endpoint, body)
+}
+
+// newRequestWithBaseURL works like newRequest, but resolves endpoint relative to base instead of the client's base URL.
+func (c *Client) newRequestWithBaseURL(ctx context.Context, base *url.URL, method string, endpoint string, body interface{}) (*http.Request, error) {
+	c.mu.RLock()
+	closed := c.closed
+	c.mu.RUnlock()
+
+	if closed {
+		return nil, ErrClientClosed
+	}
+
+	u := base.ResolveReference(&url.URL{Path: path.Join(base.Path, endpoint)})
 
 	var buf io.ReadWriter
 	if body != nil {
@@ -276,30 +1493,250 @@ func (c *Client) newRequest(ctx context.Context, method string, endpoint string,
 
 	req.Header.Set("Accept", "application/json")
 
+	// setting Accept-Encoding disables the transport's transparent decompression, gzip bodies are decompressed in send
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	req.Header.Set("User-Agent", c.config.UserAgent)
+
+	c.mu.RLock()
+	for key, values := range c.headers {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	c.mu.RUnlock()
+
+	if id, ok := RequestIDFromContext(ctx); ok {
+		req.Header.Set(requestIDHeader, id)
+	}
+
+	if tenant, ok := TenantFromContext(ctx); ok {
+		req.Header.Set(tenantHeader, tenant)
+	}
+
+	// bearer token takes precedence over basic auth if both have been configured
+	if len(c.config.AuthToken) > 0 {
+		req.Header.Set("Authorization", "Bearer "+c.config.AuthToken)
+	} else if len(c.config.Username) > 0 && len(c.config.Password) > 0 {
+		req.SetBasicAuth(c.config.Username, c.config.Password)
+	}
+
 	return req, nil
 }
 
-func (c *Client) do(req *http.Request, v interface{}) (*http.Response, error) {
-	resp, err := c.client.Do(req)
+// do sends the request, retrying idempotent requests with exponential backoff up to the configured MaxRetries
+// times as long as the manager responds with 503 Service Unavailable. Idempotent requests (and non-idempotent ones
+// if RetryNonIdempotentOnReset is enabled) are additionally retried once if the connection was reset or closed.
+func (c *Client) do(req *http.Request, v interface{}) (resp *http.Response, err error) {
+	op, _ := OperationFromContext(req.Context())
+
+	// an explicit deadline set by the caller always takes precedence over the configured operation timeout
+	if timeout := c.config.OperationTimeouts[op.Name]; timeout > 0 {
+		if _, ok := req.Context().Deadline(); !ok {
+			ctx, cancel := context.WithTimeout(req.Context(), timeout)
+			defer cancel()
+
+			req = req.WithContext(ctx)
+		}
+	}
+
+	if c.config.Events != nil {
+		start := time.Now()
+
+		defer func() {
+			event := Event{
+				Operation: op.Name,
+				Hash:      op.Hash,
+				TestID:    op.TestID,
+				Attempt:   1,
+				RequestID: req.Header.Get(requestIDHeader),
+				Duration:  time.Since(start),
+				Err:       err,
+			}
+			if op.Attempt > 0 {
+				event.Attempt = op.Attempt
+			}
+			if resp != nil {
+				event.StatusCode = resp.StatusCode
+			}
+			c.emitEvent(event)
+		}()
+	}
+
+	if c.config.Tracer != nil {
+		ctx, end := c.config.Tracer.StartSpan(req.Context(), op)
+		defer func() {
+			var statusCode int
+			if resp != nil {
+				statusCode = resp.StatusCode
+			}
+			end(statusCode, err)
+		}()
+
+		req = req.WithContext(ctx)
+	}
+
+	backoff := c.config.RetryBackoff
+	resetRetried := false
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(req, v)
+		if err != nil && req.Context().Err() != nil {
+			// return the context's error as is instead of the *url.Error wrapping it, easing to branch on it
+			return nil, req.Context().Err()
+		}
+
+		// the manager might have closed a keep-alive connection while sending the request, retry once immediately
+		if err != nil && !resetRetried && isConnectionReset(err) && (isIdempotentOnReset(req.Method, op) || c.config.RetryNonIdempotentOnReset) {
+			resetRetried = true
+			attempt-- // not counted as a retry of a 503 Service Unavailable response
+		} else {
+			// the manager rejects requests with 503 Service Unavailable before handling them, so non-idempotent ones
+			// like InitializeTemplate are safe to retry as well
+			if err != nil || resp.StatusCode != http.StatusServiceUnavailable || attempt >= c.config.MaxRetries {
+				return resp, err
+			}
+
+			// prefer the delay suggested by the manager over the configured backoff, returning the response right away
+			// if the caller's deadline would expire first, so the suggested delay is exposed via NotReadyError
+			delay := backoff
+			if retryAfter, ok := parseRetryAfter(resp); ok {
+				if deadline, ok := req.Context().Deadline(); ok && time.Until(deadline) < retryAfter {
+					return resp, nil
+				}
+
+				delay = retryAfter
+			}
+
+			if err := sleepContext(req.Context(), delay); err != nil {
+				return nil, err
+			}
+
+			backoff *= 2
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+func (c *Client) send(req *http.Request, v interface{}) (*http.Response, error) {
+	c.mu.RLock()
+	client := c.client
+	c.mu.RUnlock()
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	c.logRequest(req, resp, start, err)
 	if err != nil {
 		return nil, err
 	}
 
-	// body must always be closed
-	defer resp.Body.Close()
+	// body must always be drained and closed, allowing the underlying connection to be reused (unless it is oversized)
+	body := resp.Body
+	defer func() {
+		_, _ = io.Copy(ioutil.Discard, io.LimitReader(body, c.config.MaxResponseBytes))
+		body.Close()
+	}()
 
 	if resp.StatusCode == http.StatusAccepted || resp.StatusCode == http.StatusNoContent {
 		return resp, nil
 	}
 
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") && resp.ContentLength != 0 {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip response body: %w", err)
+		}
+		defer gz.Close()
+
+		resp.Body = gz
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Uncompressed = true
+	}
+
+	// buffer the body of unsuccessful responses, allowing callers to include it in the returned error
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, err := ioutil.ReadAll(io.LimitReader(resp.Body, c.config.MaxResponseBytes))
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		return resp, nil
+	}
+
 	// if the provided v pointer is nil we cannot unmarschal the body to anything
 	if v == nil {
 		return resp, nil
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+	// read one byte more than allowed, detecting oversized bodies instead of decoding them partially
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, c.config.MaxResponseBytes+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > c.config.MaxResponseBytes {
+		return nil, fmt.Errorf("%w: exceeds %d bytes", ErrResponseTooLarge, c.config.MaxResponseBytes)
+	}
+
+	// Health handles (possibly empty) bodies itself, the unexported type keeps this out of reach of Do
+	if raw, ok := v.(*healthResponseBody); ok {
+		*raw = data
+		return resp, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if c.config.StrictJSON {
+		dec.DisallowUnknownFields()
+	}
+
+	if err := dec.Decode(v); err != nil {
 		return nil, err
 	}
 
 	return resp, err
 }
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// isIdempotentOnReset reports whether a request of op may be sent again if the connection was reset while waiting
+// for its response. GetTestDatabase is a GET, but checks out a test database each time, so if the manager already
+// handled the first request, retrying it would leak a locked test database.
+func isIdempotentOnReset(method string, op Operation) bool {
+	return isIdempotent(method) && op.Name != "GetTestDatabase"
+}
+
+// isConnectionReset reports whether err indicates the connection to the manager was closed unexpectedly.
+func isConnectionReset(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// sleepContext pauses for the given duration, returning the context's error early if it is done before.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
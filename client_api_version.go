@@ -0,0 +1,32 @@
+package integresql
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+)
+
+type apiVersionContextKey struct{}
+
+// WithAPIVersionOverride returns a copy of ctx carrying version, which overrides the client's configured APIVersion
+// for all requests issued by a client call using the returned context, e.g. to target managers providing different
+// API versions with a single client. Use the functional option WithAPIVersion to configure the client's version.
+func WithAPIVersionOverride(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, apiVersionContextKey{}, version)
+}
+
+// baseURLFromContext returns the base URL requests are sent to, respecting an API version set via
+// WithAPIVersionOverride.
+func (c *Client) baseURLFromContext(ctx context.Context) (*url.URL, error) {
+	version, ok := ctx.Value(apiVersionContextKey{}).(string)
+	if !ok || len(version) == 0 || version == c.config.APIVersion {
+		return c.baseURL, nil
+	}
+
+	if !apiVersionRegexp.MatchString(version) {
+		return nil, fmt.Errorf("invalid API version %q: must match %s", version, apiVersionRegexp)
+	}
+
+	return c.rootURL.ResolveReference(&url.URL{Path: path.Join(c.rootURL.Path, version)}), nil
+}
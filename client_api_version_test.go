@@ -0,0 +1,53 @@
+package integresql
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestClientAPIVersionOverride(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu    sync.Mutex
+		paths []string
+	)
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	ctx := context.Background()
+
+	if err := c.DiscardTemplate(WithAPIVersionOverride(ctx, "v2"), "hash"); err != nil {
+		t.Fatalf("failed to discard template: %v", err)
+	}
+
+	if err := c.DiscardTemplate(ctx, "hash"); err != nil {
+		t.Fatalf("failed to discard template: %v", err)
+	}
+
+	if err := c.DiscardTemplate(WithAPIVersionOverride(ctx, "2"), "hash"); err == nil {
+		t.Error("invalid error, got nil, want error for invalid API version")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	want := []string{"/api/v2/templates/hash", "/api/v1/templates/hash"}
+	if len(paths) != len(want) {
+		t.Fatalf("invalid requests, got %v, want %v", paths, want)
+	}
+
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Errorf("invalid path of request %d, got %q, want %q", i, paths[i], want[i])
+		}
+	}
+}
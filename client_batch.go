@@ -0,0 +1,226 @@
+package integresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/allaboutapps/integresql-client-go/pkg/models"
+)
+
+// maxConcurrentRequests limits the number of requests batch operations issue against the manager concurrently.
+const maxConcurrentRequests = 4
+
+// GetTestDatabases concurrently retrieves count test databases for the template identified by hash.
+// If some retrievals fail, the test databases retrieved successfully are returned alongside the error,
+// allowing the caller to return them to the pool. A negative count is rejected.
+func (c *Client) GetTestDatabases(ctx context.Context, hash string, count int) ([]models.TestDatabase, error) {
+	if count < 0 {
+		return nil, fmt.Errorf("invalid count %d: must not be negative", count)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		tests    = make([]models.TestDatabase, 0, count)
+		failed   int
+		firstErr error
+	)
+
+	sem := make(chan struct{}, maxConcurrentRequests)
+
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			test, err := c.GetTestDatabase(ctx, hash)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				failed++
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+
+			tests = append(tests, test)
+		}()
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return tests, fmt.Errorf("failed to get %d of %d test databases: %w", failed, count, firstErr)
+	}
+
+	return tests, nil
+}
+
+// DiscardAllTemplates lists all templates tracked by the manager and discards each of them, dropping the
+// template databases themselves (unlike ResetAllTracking). Failures discarding individual templates do not
+// stop the remaining ones from being discarded, all errors encountered are returned combined.
+func (c *Client) DiscardAllTemplates(ctx context.Context) error {
+	templates, err := c.ListTemplates(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.discardTemplates(ctx, templates, "")
+}
+
+// DiscardTemplatesByPrefix lists all templates tracked by the manager and discards the ones whose hash starts with
+// prefix, e.g. to only clean up the templates of a single service on a shared manager. Like DiscardAllTemplates,
+// all matching templates are discarded even if some of them fail, the errors encountered are returned combined.
+// It fails with a descriptive error if the manager does not support listing templates. An empty prefix is rejected,
+// use DiscardAllTemplates to discard all templates.
+func (c *Client) DiscardTemplatesByPrefix(ctx context.Context, prefix string) error {
+	if prefix == "" {
+		return errors.New("discard templates by prefix: invalid prefix: must not be empty")
+	}
+
+	templates, err := c.ListTemplates(ctx)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusMethodNotAllowed) {
+			return fmt.Errorf("discard templates by prefix %q: listing templates is not supported by the manager: %w", prefix, err)
+		}
+
+		return fmt.Errorf("discard templates by prefix %q: %w", prefix, err)
+	}
+
+	return c.discardTemplates(ctx, templates, prefix)
+}
+
+// discardTemplates discards the given templates whose hash starts with prefix (all of them if prefix is empty),
+// continuing on failures and returning all errors encountered combined.
+func (c *Client) discardTemplates(ctx context.Context, templates []models.TemplateDatabase, prefix string) error {
+	var errs []error
+	for _, template := range templates {
+		if !strings.HasPrefix(template.TemplateHash, prefix) {
+			continue
+		}
+
+		if err := c.DiscardTemplate(ctx, template.TemplateHash); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// SetupTemplates concurrently sets up the templates identified by the keys of inits via SetupTemplateWithDBClient,
+// passing each template database to its init function. All templates are set up even if some of them fail,
+// the errors encountered are returned combined, each identifying the hash of the template that failed.
+func (c *Client) SetupTemplates(ctx context.Context, inits map[string]func(db *sql.DB) error) error {
+	hashes := make([]string, 0, len(inits))
+	for hash := range inits {
+		hashes = append(hashes, hash)
+	}
+
+	sort.Strings(hashes)
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(hashes))
+	sem := make(chan struct{}, maxConcurrentRequests)
+
+	for i, hash := range hashes {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, hash string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			if err := c.SetupTemplateWithDBClient(ctx, hash, inits[hash]); err != nil {
+				errs[i] = fmt.Errorf("setup template %q: %w", hash, err)
+			}
+		}(i, hash)
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// ReturnTestDatabases concurrently releases the test databases with the given IDs to the pool of the template
+// identified by hash via ReleaseTestDatabase, e.g. as a safety net in a suite-level defer for tests which skipped
+// their cleanup. As those test databases might have been modified, they are recreated (unless UnlockOnRelease is
+// enabled). Test databases unknown to the manager (404 Not Found) are skipped as they might have been returned
+// already, all other errors encountered are returned combined.
+func (c *Client) ReturnTestDatabases(ctx context.Context, hash string, ids []models.TestDatabaseID) error {
+	return c.eachTestDatabase(ctx, hash, ids, c.ReleaseTestDatabase)
+}
+
+// eachTestDatabase concurrently calls fn for the test databases with the given IDs, skipping test databases unknown
+// to the manager and returning all other errors encountered combined.
+func (c *Client) eachTestDatabase(ctx context.Context, hash string, ids []models.TestDatabaseID, fn func(ctx context.Context, hash string, id models.TestDatabaseID) error) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(ids))
+	sem := make(chan struct{}, maxConcurrentRequests)
+
+	for i, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, id models.TestDatabaseID) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			if err := fn(ctx, hash, id); err != nil && !errors.Is(err, ErrTemplateNotFound) {
+				errs[i] = err
+			}
+		}(i, id)
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// PrewarmTemplate ensures count test databases of the template identified by hash have been generated, so the
+// first count retrievals of a test suite do not have to wait for the manager. As IntegreSQL offers no endpoint to
+// hint the desired pool size, this is emulated client-side: count test databases are retrieved concurrently via
+// GetTestDatabases and immediately returned unchanged via ReturnTestDatabase. Keep count within the pool size
+// configured on the manager, otherwise retrievals block until test databases are returned. Test databases retrieved
+// before an error occurred are still returned, all errors encountered are returned combined. A negative count is
+// rejected.
+func (c *Client) PrewarmTemplate(ctx context.Context, hash string, count int) error {
+	if count < 0 {
+		return fmt.Errorf("prewarm template %q: invalid count %d: must not be negative", hash, count)
+	}
+
+	tests, err := c.GetTestDatabases(ctx, hash, count)
+	if err != nil {
+		err = fmt.Errorf("prewarm template %q: %w", hash, err)
+	}
+
+	ids := make([]models.TestDatabaseID, 0, len(tests))
+	for _, test := range tests {
+		ids = append(ids, test.ID)
+	}
+
+	// the test databases were never used, so they can be handed out again as is without recreating them
+	if returnErr := c.eachTestDatabase(ctx, hash, ids, c.ReturnTestDatabase); returnErr != nil {
+		err = errors.Join(err, fmt.Errorf("prewarm template %q: %w", hash, returnErr))
+	}
+
+	return err
+}
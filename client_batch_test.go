@@ -0,0 +1,336 @@
+package integresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/allaboutapps/integresql-client-go/pkg/models"
+)
+
+func TestClientGetTestDatabases(t *testing.T) {
+	t.Parallel()
+
+	var id int32
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":%d,"database":{"templateHash":"hash"}}`, atomic.AddInt32(&id, 1))
+	})
+
+	tests, err := c.GetTestDatabases(context.Background(), "hash", 10)
+	if err != nil {
+		t.Fatalf("failed to get test databases: %v", err)
+	}
+
+	if len(tests) != 10 {
+		t.Fatalf("invalid number of test databases, got %d, want %d", len(tests), 10)
+	}
+
+	seen := make(map[models.TestDatabaseID]bool)
+	for _, test := range tests {
+		if seen[test.ID] {
+			t.Errorf("received test database %d more than once", test.ID)
+		}
+		seen[test.ID] = true
+	}
+}
+
+func TestClientGetTestDatabasesNegativeCount(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("invalid request, got %s %s", r.Method, r.URL.Path)
+	})
+
+	if _, err := c.GetTestDatabases(context.Background(), "hash", -1); err == nil {
+		t.Error("invalid error, got nil, want negative count to be rejected")
+	}
+
+	if err := c.PrewarmTemplate(context.Background(), "hash", -1); err == nil {
+		t.Error("invalid error, got nil, want negative count to be rejected")
+	}
+}
+
+func TestClientGetTestDatabasesPartialFailure(t *testing.T) {
+	t.Parallel()
+
+	var id int32
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&id, 1)
+		if n%2 == 0 {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":%d,"database":{"templateHash":"hash"}}`, n)
+	})
+
+	tests, err := c.GetTestDatabases(context.Background(), "hash", 6)
+	if !errors.Is(err, ErrDatabaseDiscarded) {
+		t.Errorf("invalid error, got %v, want %v", err, ErrDatabaseDiscarded)
+	}
+
+	if len(tests) != 3 {
+		t.Errorf("invalid number of test databases, got %d, want %d", len(tests), 3)
+	}
+}
+
+func TestClientDiscardAllTemplates(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu        sync.Mutex
+		discarded []string
+	)
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/admin/templates":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`[{"database":{"templateHash":"hash1"}},{"database":{"templateHash":"hash2"}},{"database":{"templateHash":"hash3"}}]`))
+		case r.Method == http.MethodDelete:
+			mu.Lock()
+			discarded = append(discarded, r.URL.Path)
+			mu.Unlock()
+
+			if r.URL.Path == "/api/v1/templates/hash2" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("invalid request, got %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	err := c.DiscardAllTemplates(context.Background())
+	if !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("invalid error, got %v, want %v", err, ErrTemplateNotFound)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(discarded) != 3 {
+		t.Errorf("invalid number of discarded templates, got %d, want %d", len(discarded), 3)
+	}
+}
+
+func TestClientDiscardTemplatesByPrefix(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu        sync.Mutex
+		discarded []string
+	)
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/admin/templates":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`[{"database":{"templateHash":"svc-orders-1"}},{"database":{"templateHash":"svc-users-1"}},{"database":{"templateHash":"svc-orders-2"}}]`))
+		case r.Method == http.MethodDelete:
+			mu.Lock()
+			discarded = append(discarded, r.URL.Path)
+			mu.Unlock()
+
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("invalid request, got %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	if err := c.DiscardTemplatesByPrefix(context.Background(), "svc-orders-"); err != nil {
+		t.Fatalf("failed to discard templates: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	want := []string{"/api/v1/templates/svc-orders-1", "/api/v1/templates/svc-orders-2"}
+	if len(discarded) != len(want) {
+		t.Fatalf("invalid discarded templates, got %v, want %v", discarded, want)
+	}
+
+	for i := range want {
+		if discarded[i] != want[i] {
+			t.Errorf("invalid discarded template %d, got %q, want %q", i, discarded[i], want[i])
+		}
+	}
+}
+
+func TestClientDiscardTemplatesByPrefixListingUnsupported(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("invalid request, got %s %s", r.Method, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	err := c.DiscardTemplatesByPrefix(context.Background(), "svc-orders-")
+	if err == nil || !strings.Contains(err.Error(), "listing templates is not supported") {
+		t.Errorf("invalid error, got %v, want listing templates to be reported as unsupported", err)
+	}
+}
+
+func TestClientDiscardTemplatesByPrefixEmptyPrefix(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("invalid request, got %s %s", r.Method, r.URL.Path)
+	})
+
+	if err := c.DiscardTemplatesByPrefix(context.Background(), ""); err == nil {
+		t.Error("invalid error, got nil, want empty prefix to be rejected")
+	}
+}
+
+func TestClientSetupTemplates(t *testing.T) {
+	t.Parallel()
+
+	var requests int32
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		var payload struct {
+			Hash string `json:"hash"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("failed to decode payload: %v", err)
+		}
+
+		switch payload.Hash {
+		case "failing":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusLocked)
+		}
+	})
+
+	init := func(db *sql.DB) error {
+		t.Error("init must not be called for already initialized templates")
+		return nil
+	}
+
+	err := c.SetupTemplates(context.Background(), map[string]func(db *sql.DB) error{
+		"a":       init,
+		"b":       init,
+		"c":       init,
+		"failing": init,
+	})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("invalid error, got %v, want *APIError", err)
+	}
+
+	if !strings.Contains(err.Error(), `setup template "failing"`) {
+		t.Errorf("invalid error message, got %q, want it to contain the failing hash", err.Error())
+	}
+
+	if strings.Contains(err.Error(), `setup template "a"`) {
+		t.Errorf("invalid error message, got %q, want it to only contain the failing hash", err.Error())
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 4 {
+		t.Errorf("invalid number of requests, got %d, want %d", n, 4)
+	}
+}
+
+func TestClientReturnTestDatabases(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		returned []string
+	)
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("invalid method, got %s, want %s", r.Method, http.MethodPost)
+		}
+
+		mu.Lock()
+		returned = append(returned, r.URL.Path)
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/api/v1/templates/hash/tests/2/recreate":
+			w.WriteHeader(http.StatusNotFound)
+		case "/api/v1/templates/hash/tests/3/recreate":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	err := c.ReturnTestDatabases(context.Background(), "hash", []models.TestDatabaseID{0, 1, 2, 3, 4})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("invalid error, got %v, want *APIError with status %d", err, http.StatusInternalServerError)
+	}
+
+	if errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("invalid error, got %v, want already returned test databases to be skipped", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(returned) != 5 {
+		t.Errorf("invalid number of returned test databases, got %d, want %d", len(returned), 5)
+	}
+}
+
+func TestClientPrewarmTemplate(t *testing.T) {
+	t.Parallel()
+
+	var (
+		id       int32
+		mu       sync.Mutex
+		returned []string
+	)
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			n := atomic.AddInt32(&id, 1)
+			if n == 4 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"id":%d,"database":{"templateHash":"hash"}}`, n)
+		case http.MethodDelete:
+			mu.Lock()
+			returned = append(returned, r.URL.Path)
+			mu.Unlock()
+
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("invalid request, got %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	err := c.PrewarmTemplate(context.Background(), "hash", 5)
+	if !errors.Is(err, ErrManagerNotReady) {
+		t.Errorf("invalid error, got %v, want %v", err, ErrManagerNotReady)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(returned) != 4 {
+		t.Errorf("invalid number of returned test databases, got %d, want %d", len(returned), 4)
+	}
+}
@@ -1,15 +1,157 @@
 package integresql
 
-import "github.com/allaboutapps/integresql-client-go/pkg/util"
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"runtime/debug"
+	"time"
+
+	"github.com/allaboutapps/integresql-client-go/pkg/util"
+)
+
+var apiVersionRegexp = regexp.MustCompile(`^v[0-9]+$`)
+
+// API versions of the IntegreSQL manager known to this client, to be used as ClientConfig.APIVersion.
+// IntegreSQL currently only provides v1 (regardless of the server version), newer API versions will be added here.
+const (
+	APIVersionV1 = "v1"
+)
+
+// Version of this client sent as part of the default User-Agent. It is determined from the build info if the
+// module is used as a dependency, or can be set at build time via
+// -ldflags "-X github.com/allaboutapps/integresql-client-go.Version=v1.2.3".
+var Version = "dev"
+
+const modulePath = "github.com/allaboutapps/integresql-client-go"
+
+// supportedAPIVersions lists the API versions NegotiateAPIVersion probes for, newest first.
+var supportedAPIVersions = []string{APIVersionV1}
+
+// maxGetTestDatabaseBackoff caps the backoff between retries of GetTestDatabase.
+const maxGetTestDatabaseBackoff = time.Second
+
+// maxPingBackoff caps the backoff between retries of pinging a freshly opened database.
+const maxPingBackoff = time.Second
 
 type ClientConfig struct {
-	BaseURL    string
-	APIVersion string
+	BaseURL      string
+	APIVersion   string
+	MaxRetries   int           // Maximum number of retries for requests the manager responded to with 503 Service Unavailable, regardless of their method
+	RetryBackoff time.Duration // Initial backoff between retries, doubled after each attempt
+	AuthToken    string        // Optional bearer token sent via the Authorization header, never logged
+	Username     string        // Optional username for HTTP basic auth, only used if Password is set as well and no AuthToken was configured
+	Password     string        // Optional password for HTTP basic auth, never logged
+	Headers      http.Header   // Optional default headers sent with every request, overriding the client's Content-Type/Accept if set
+	Timeout      time.Duration // Timeout of the HTTP client, limiting requests even if no context deadline was set
+	DriverName   string        // Name of the database/sql driver used to open connections, lib/pq is registered as "postgres" unless built with the integresql_nopq tag
+	Logger       Logger        // Optional logger invoked after each request sent to the manager
+	Tracer       Tracer        // Optional tracer starting a span for each operation sent to the manager, see pkg/integresqlotel
+	Metrics      Metrics       // Optional metrics recorded for each request sent to the manager, see pkg/integresqlprom
+	Events       chan<- Event  // Optional channel receiving an Event for each operation sent to the manager, events are dropped if it is full
+	TLSConfig    *tls.Config   // Optional TLS config used to connect to the manager, e.g. to trust an internal CA
+	Proxy        string        // Optional URL of an HTTP or SOCKS5 proxy to connect to the manager, HTTP_PROXY/NO_PROXY are respected if empty
+	OverrideHost string        // Optional host replacing the one reported by the manager in returned database configs, e.g. "localhost" if the manager runs in Docker
+	OverridePort int           // Optional port replacing the one reported by the manager in returned database configs
+
+	StrictJSON       bool  // Fail decoding responses of the manager containing unknown fields, e.g. to detect API drift
+	MaxResponseBytes int64 // Maximum size of response bodies read from the manager in bytes, larger bodies fail with ErrResponseTooLarge
+
+	RetryNonIdempotentOnReset bool // Retry non-idempotent requests (e.g. InitializeTemplate, GetTestDatabase) once if the connection was reset, idempotent ones are always retried once
+
+	DiscardTemplateOnInitError bool // Discard the template if its init fails during SetupTemplate and its variants, allowing the next run to start clean
+
+	UnlockOnRelease bool // Return test databases released by helpers (e.g. WithTestDatabase) as is instead of recreating them, required for IntegreSQL v1.0.x servers
+
+	ConnectionRewrite ConnectionRewriteFunc // Optional rewrite of database configs returned by the manager, applied after OverrideHost/OverridePort, see WithConnectionRewrite
+
+	PathPrefix string // Optional path joined to BaseURL before the API version, e.g. "/integresql/api" if the manager is mounted there by a reverse proxy
+
+	UserAgent string // User-Agent sent with every request, allowing operators to attribute requests to services, defaults to "integresql-client-go/<version>"
+
+	OperationTimeouts map[string]time.Duration // Optional timeouts per operation keyed by method name (e.g. "FinalizeTemplate"), only applied if the caller's context has no deadline, Timeout still limits each request
+
+	GetTestDatabaseRetries int           // Maximum number of retries if the manager's pool of test databases is temporarily exhausted (429 Too Many Requests)
+	GetTestDatabaseBackoff time.Duration // Initial backoff between test database retries, doubled after each attempt up to 1s
+
+	PingRetries int           // Maximum number of retries if pinging a freshly opened template or test database fails, as Postgres might not accept connections yet
+	PingBackoff time.Duration // Initial backoff between ping retries, doubled after each attempt up to 1s
 }
 
 func DefaultClientConfigFromEnv() ClientConfig {
 	return ClientConfig{
-		BaseURL:    util.GetEnv("INTEGRESQL_CLIENT_BASE_URL", "http://integresql:5000/api"),
-		APIVersion: util.GetEnv("INTEGRESQL_CLIENT_API_VERSION", "v1"),
+		BaseURL:      util.GetEnv("INTEGRESQL_CLIENT_BASE_URL", "http://integresql:5000/api"),
+		APIVersion:   util.GetEnv("INTEGRESQL_CLIENT_API_VERSION", APIVersionV1),
+		MaxRetries:   util.GetEnvAsInt("INTEGRESQL_CLIENT_MAX_RETRIES", 0),
+		RetryBackoff: util.GetEnvAsDuration("INTEGRESQL_CLIENT_RETRY_BACKOFF", 100*time.Millisecond),
+		AuthToken:    util.GetEnv("INTEGRESQL_CLIENT_AUTH_TOKEN", ""),
+		Username:     util.GetEnv("INTEGRESQL_CLIENT_USERNAME", ""),
+		Password:     util.GetEnv("INTEGRESQL_CLIENT_PASSWORD", ""),
+		Timeout:      util.GetEnvAsDuration("INTEGRESQL_CLIENT_TIMEOUT", 30*time.Second),
+		DriverName:   util.GetEnv("INTEGRESQL_CLIENT_DRIVER_NAME", "postgres"),
+		Proxy:        util.GetEnv("INTEGRESQL_CLIENT_PROXY", ""),
+		OverrideHost: util.GetEnv("INTEGRESQL_CLIENT_OVERRIDE_HOST", ""),
+		OverridePort: util.GetEnvAsInt("INTEGRESQL_CLIENT_OVERRIDE_PORT", 0),
+
+		StrictJSON:       util.GetEnvAsBool("INTEGRESQL_CLIENT_STRICT_JSON", false),
+		MaxResponseBytes: int64(util.GetEnvAsInt("INTEGRESQL_CLIENT_MAX_RESPONSE_BYTES", 4<<20)),
+
+		RetryNonIdempotentOnReset: util.GetEnvAsBool("INTEGRESQL_CLIENT_RETRY_NON_IDEMPOTENT_ON_RESET", false),
+
+		DiscardTemplateOnInitError: util.GetEnvAsBool("INTEGRESQL_CLIENT_DISCARD_TEMPLATE_ON_INIT_ERROR", false),
+
+		UnlockOnRelease: util.GetEnvAsBool("INTEGRESQL_CLIENT_UNLOCK_ON_RELEASE", false),
+
+		PathPrefix: util.GetEnv("INTEGRESQL_CLIENT_PATH_PREFIX", ""),
+
+		UserAgent: util.GetEnv("INTEGRESQL_CLIENT_USER_AGENT", defaultUserAgent()),
+
+		GetTestDatabaseRetries: util.GetEnvAsInt("INTEGRESQL_CLIENT_GET_TEST_DATABASE_RETRIES", 0),
+		GetTestDatabaseBackoff: util.GetEnvAsDuration("INTEGRESQL_CLIENT_GET_TEST_DATABASE_BACKOFF", 50*time.Millisecond),
+
+		PingRetries: util.GetEnvAsInt("INTEGRESQL_CLIENT_PING_RETRIES", 3),
+		PingBackoff: util.GetEnvAsDuration("INTEGRESQL_CLIENT_PING_BACKOFF", 50*time.Millisecond),
+	}
+}
+
+// defaultUserAgent returns the User-Agent identifying this client, preferring the module version of the build info
+// over Version unless the latter has been set at build time.
+func defaultUserAgent() string {
+	version := Version
+
+	if info, ok := debug.ReadBuildInfo(); ok && version == "dev" {
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath && len(dep.Version) > 0 {
+				version = dep.Version
+				break
+			}
+		}
+	}
+
+	return "integresql-client-go/" + version
+}
+
+// Validate checks the config for common misconfigurations, ensuring BaseURL is an absolute http(s) URL
+// and APIVersion looks like a valid version (e.g. v1).
+func (c ClientConfig) Validate() error {
+	u, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return fmt.Errorf("invalid base URL %q: %w", c.BaseURL, err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid base URL %q: scheme must be http or https", c.BaseURL)
 	}
+
+	if len(u.Host) == 0 {
+		return fmt.Errorf("invalid base URL %q: host is missing", c.BaseURL)
+	}
+
+	if !apiVersionRegexp.MatchString(c.APIVersion) {
+		return fmt.Errorf("invalid API version %q: must match %s", c.APIVersion, apiVersionRegexp)
+	}
+
+	return nil
 }
@@ -0,0 +1,66 @@
+package integresql
+
+import "testing"
+
+func TestClientConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		config  ClientConfig
+		wantErr bool
+	}{
+		{
+			name:   "Valid",
+			config: ClientConfig{BaseURL: "http://integresql:5000/api", APIVersion: "v1"},
+		},
+		{
+			name:   "HTTPS",
+			config: ClientConfig{BaseURL: "https://integresql.example.com/api", APIVersion: "v2"},
+		},
+		{
+			name:    "InvalidScheme",
+			config:  ClientConfig{BaseURL: "ftp://integresql:5000/api", APIVersion: "v1"},
+			wantErr: true,
+		},
+		{
+			name:    "MissingScheme",
+			config:  ClientConfig{BaseURL: "integresql:5000/api", APIVersion: "v1"},
+			wantErr: true,
+		},
+		{
+			name:    "MissingHost",
+			config:  ClientConfig{BaseURL: "http:///api", APIVersion: "v1"},
+			wantErr: true,
+		},
+		{
+			name:    "Unparsable",
+			config:  ClientConfig{BaseURL: "http://integresql:port/api", APIVersion: "v1"},
+			wantErr: true,
+		},
+		{
+			name:    "InvalidAPIVersion",
+			config:  ClientConfig{BaseURL: "http://integresql:5000/api", APIVersion: "version1"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("invalid validation result, got %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewClientInvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewClient(ClientConfig{BaseURL: "integresql:5000", APIVersion: "v1"}); err == nil {
+		t.Error("creating a client with an invalid config should have failed")
+	}
+}
@@ -0,0 +1,51 @@
+package integresql
+
+import (
+	"context"
+
+	"github.com/allaboutapps/integresql-client-go/pkg/models"
+)
+
+// NewClientWithContext works like NewClient, but sets ctx as the client's base context, which is used by the
+// *Default convenience methods (e.g. InitializeTemplateDefault) instead of an explicitly passed context.
+// This is meant for short-lived tools, methods taking an explicit context remain the primary API.
+func NewClientWithContext(ctx context.Context, config ClientConfig) (*Client, error) {
+	c, err := NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	c.ctx = ctx
+
+	return c, nil
+}
+
+// InitializeTemplateDefault calls InitializeTemplate using the client's base context.
+func (c *Client) InitializeTemplateDefault(hash string) (models.TemplateDatabase, error) {
+	return c.InitializeTemplate(c.ctx, hash)
+}
+
+// FinalizeTemplateDefault calls FinalizeTemplate using the client's base context.
+func (c *Client) FinalizeTemplateDefault(hash string) error {
+	return c.FinalizeTemplate(c.ctx, hash)
+}
+
+// DiscardTemplateDefault calls DiscardTemplate using the client's base context.
+func (c *Client) DiscardTemplateDefault(hash string) error {
+	return c.DiscardTemplate(c.ctx, hash)
+}
+
+// GetTestDatabaseDefault calls GetTestDatabase using the client's base context.
+func (c *Client) GetTestDatabaseDefault(hash string) (models.TestDatabase, error) {
+	return c.GetTestDatabase(c.ctx, hash)
+}
+
+// ReturnTestDatabaseDefault calls ReturnTestDatabase using the client's base context.
+func (c *Client) ReturnTestDatabaseDefault(hash string, id models.TestDatabaseID) error {
+	return c.ReturnTestDatabase(c.ctx, hash, id)
+}
+
+// RecreateTestDatabaseDefault calls RecreateTestDatabase using the client's base context.
+func (c *Client) RecreateTestDatabaseDefault(hash string, id models.TestDatabaseID) error {
+	return c.RecreateTestDatabase(c.ctx, hash, id)
+}
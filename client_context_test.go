@@ -0,0 +1,35 @@
+package integresql
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewClientWithContext(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c, err := NewClientWithContext(ctx, ClientConfig{BaseURL: srv.URL + "/api", APIVersion: "v1"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	if err := c.FinalizeTemplateDefault("hash"); err != nil {
+		t.Fatalf("failed to finalize template: %v", err)
+	}
+
+	cancel()
+
+	if err := c.FinalizeTemplateDefault("hash"); !errors.Is(err, context.Canceled) {
+		t.Errorf("invalid error, got %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,147 @@
+package integresql
+
+import (
+	"container/list"
+	"database/sql"
+	"errors"
+	"sync"
+
+	"github.com/allaboutapps/integresql-client-go/pkg/models"
+)
+
+// maxCachedTestDatabases limits the number of idle connection pools kept open by OpenTestDatabase.
+const maxCachedTestDatabases = 16
+
+// defaultMaxIdleConns matches the default number of idle connections retained by database/sql.
+const defaultMaxIdleConns = 2
+
+// dbCache keeps connection pools keyed by their connection string. Pools are reference-counted by the test databases
+// handed out for them and only closed once they have been released, the least recently used released pool is closed
+// once more than size pools are cached. Pools in use are never closed (except by close), so the cache might
+// temporarily grow beyond size if more test databases are in use at once.
+type dbCache struct {
+	mu      sync.Mutex
+	size    int
+	lru     *list.List // most recently used entry at the front
+	entries map[string]*list.Element
+	tests   map[testDatabaseKey]*list.Element // entries by the test databases they were handed out for
+}
+
+type dbCacheEntry struct {
+	dsn  string
+	db   *sql.DB
+	refs int // number of test databases handed out for this pool which have not been released yet
+}
+
+// testDatabaseKey identifies a test database handed out by the manager.
+type testDatabaseKey struct {
+	hash string
+	id   models.TestDatabaseID
+}
+
+func newDBCache(size int) *dbCache {
+	return &dbCache{
+		size:    size,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+		tests:   make(map[testDatabaseKey]*list.Element),
+	}
+}
+
+// get returns the cached connection pool for dsn, opening a new one using driverName if none is cached yet. The pool
+// is held for the test database identified by hash and id until it is released.
+func (c *dbCache) get(driverName string, dsn string, hash string, id models.TestDatabaseID) (*sql.DB, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := testDatabaseKey{hash: hash, id: id}
+
+	if elem, ok := c.entries[dsn]; ok {
+		c.lru.MoveToFront(elem)
+
+		entry := elem.Value.(*dbCacheEntry)
+		if _, held := c.tests[key]; !held {
+			if entry.refs == 0 {
+				entry.db.SetMaxIdleConns(defaultMaxIdleConns)
+			}
+			entry.refs++
+			c.tests[key] = elem
+		}
+
+		return entry.db, nil
+	}
+
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	elem := c.lru.PushFront(&dbCacheEntry{dsn: dsn, db: db, refs: 1})
+	c.entries[dsn] = elem
+	c.tests[key] = elem
+
+	c.evict()
+
+	return db, nil
+}
+
+// release releases the connection pool held for the test database identified by hash and id, as it was given back
+// to the manager. Idle connections of the pool are closed, as they would prevent the manager from recreating the
+// test database, the pool itself is kept open for the next time the test database is handed out.
+func (c *dbCache) release(hash string, id models.TestDatabaseID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := testDatabaseKey{hash: hash, id: id}
+
+	elem, ok := c.tests[key]
+	if !ok {
+		return
+	}
+	delete(c.tests, key)
+
+	entry := elem.Value.(*dbCacheEntry)
+	entry.refs--
+	if entry.refs == 0 {
+		entry.db.SetMaxIdleConns(0)
+	}
+
+	c.evict()
+}
+
+// evict closes released pools, least recently used first, until at most size pools are cached.
+func (c *dbCache) evict() {
+	for elem := c.lru.Back(); elem != nil && c.lru.Len() > c.size; {
+		prev := elem.Prev()
+
+		entry := elem.Value.(*dbCacheEntry)
+		if entry.refs == 0 {
+			c.lru.Remove(elem)
+			delete(c.entries, entry.dsn)
+
+			// errors closing evicted pools are not actionable for the caller requesting or releasing another pool
+			_ = entry.db.Close()
+		}
+
+		elem = prev
+	}
+}
+
+// close closes and removes all cached connection pools, returning all errors encountered combined.
+func (c *dbCache) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errs []error
+	for elem := c.lru.Front(); elem != nil; elem = elem.Next() {
+		if err := elem.Value.(*dbCacheEntry).db.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	c.lru.Init()
+	c.entries = make(map[string]*list.Element)
+	c.tests = make(map[testDatabaseKey]*list.Element)
+
+	return errors.Join(errs...)
+}
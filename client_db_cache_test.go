@@ -0,0 +1,63 @@
+package integresql
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/allaboutapps/integresql-client-go/pkg/models"
+)
+
+func TestDBCacheEviction(t *testing.T) {
+	t.Parallel()
+
+	cache := newDBCache(2)
+
+	dbs := make([]*sql.DB, 3)
+	for i := range dbs {
+		db, err := cache.get("integresql_stub", fmt.Sprintf("dbname=evict_%d", i), "hash", models.TestDatabaseID(i))
+		if err != nil {
+			t.Fatalf("failed to get connection pool: %v", err)
+		}
+		dbs[i] = db
+	}
+
+	// all pools are still in use and must not be closed, even though the cache exceeds its size
+	for i, db := range dbs {
+		if err := db.Ping(); err != nil {
+			t.Errorf("invalid error pinging connection pool %d in use, got %v, want nil", i, err)
+		}
+	}
+
+	cache.release("hash", 0)
+
+	if err := dbs[0].Ping(); err == nil {
+		t.Error("invalid error pinging evicted connection pool, got nil, want error")
+	}
+
+	for _, db := range dbs[1:] {
+		if err := db.Ping(); err != nil {
+			t.Errorf("invalid error pinging cached connection pool, got %v, want nil", err)
+		}
+	}
+
+	// released pools within the size of the cache are kept open for the next time the test database is handed out
+	cache.release("hash", 1)
+
+	db, err := cache.get("integresql_stub", "dbname=evict_1", "hash", 1)
+	if err != nil {
+		t.Fatalf("failed to get connection pool: %v", err)
+	}
+
+	if db != dbs[1] {
+		t.Error("invalid connection pool, got a new pool, want the cached one")
+	}
+
+	if err := db.Ping(); err != nil {
+		t.Errorf("invalid error pinging reused connection pool, got %v, want nil", err)
+	}
+
+	if err := cache.close(); err != nil {
+		t.Fatalf("failed to close cache: %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package integresql
+
+import (
+	"time"
+
+	"github.com/allaboutapps/integresql-client-go/pkg/models"
+)
+
+// Event describes an operation the client sent to the manager, pushed to ClientConfig.Events once it completed.
+// Operations retried by the client on their own (e.g. GetTestDatabase on 429 Too Many Requests) push an event per
+// attempt, distinguished by Attempt.
+type Event struct {
+	Operation  string                // name of the client method, e.g. "GetTestDatabase"
+	Hash       string                // template hash, if any
+	TestID     models.TestDatabaseID // test database ID, if any
+	Attempt    int                   // 1-based attempt of the operation, greater than 1 for retried operations
+	RequestID  string                // X-Request-ID sent with the request, either set via WithRequestID or generated by the client
+	StatusCode int                   // final status code, 0 if no response was received
+	Duration   time.Duration         // total duration including all retried attempts
+	Err        error                 // error sending the request, if any (unexpected status codes are not reported here)
+}
+
+// emitEvent pushes event to the configured Events channel without blocking, dropping it if the channel is full.
+func (c *Client) emitEvent(event Event) {
+	if c.config.Events == nil {
+		return
+	}
+
+	select {
+	case c.config.Events <- event:
+	default:
+	}
+}
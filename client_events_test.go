@@ -0,0 +1,87 @@
+package integresql
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientEvents(t *testing.T) {
+	t.Parallel()
+
+	events := make(chan Event, 1)
+
+	c := newStubClientWithConfig(t, ClientConfig{Events: events}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	ctx := context.Background()
+
+	if err := c.UnlockTestDatabase(ctx, "hash", 7); err != nil {
+		t.Fatalf("failed to unlock test database: %v", err)
+	}
+
+	// the channel is full, further events must be dropped instead of blocking the client
+	if err := c.FinalizeTemplate(ctx, "hash"); err != nil {
+		t.Fatalf("failed to finalize template: %v", err)
+	}
+
+	event := <-events
+
+	if event.Operation != "UnlockTestDatabase" {
+		t.Errorf("invalid operation, got %q, want %q", event.Operation, "UnlockTestDatabase")
+	}
+
+	if event.Attempt != 1 {
+		t.Errorf("invalid attempt, got %d, want %d", event.Attempt, 1)
+	}
+
+	if event.Hash != "hash" || event.TestID != 7 {
+		t.Errorf("invalid hash and test ID, got %q and %d, want %q and %d", event.Hash, event.TestID, "hash", 7)
+	}
+
+	if event.StatusCode != http.StatusNoContent {
+		t.Errorf("invalid status code, got %d, want %d", event.StatusCode, http.StatusNoContent)
+	}
+
+	if event.Duration <= 0 {
+		t.Errorf("invalid duration, got %v, want > 0", event.Duration)
+	}
+
+	select {
+	case event := <-events:
+		t.Errorf("invalid event, got %+v, want it to be dropped", event)
+	default:
+	}
+}
+
+func TestClientEventsRetriedOperation(t *testing.T) {
+	t.Parallel()
+
+	events := make(chan Event, 3)
+
+	var calls int32
+	config := ClientConfig{Events: events, GetTestDatabaseRetries: 2, GetTestDatabaseBackoff: time.Millisecond}
+	c := newStubClientWithConfig(t, config, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":1,"database":{"templateHash":"hash"}}`))
+	})
+
+	if _, err := c.GetTestDatabase(context.Background(), "hash"); err != nil {
+		t.Fatalf("failed to get test database: %v", err)
+	}
+
+	for want := 1; want <= 3; want++ {
+		event := <-events
+
+		if event.Operation != "GetTestDatabase" || event.Attempt != want {
+			t.Errorf("invalid operation and attempt, got %q and %d, want %q and %d", event.Operation, event.Attempt, "GetTestDatabase", want)
+		}
+	}
+}
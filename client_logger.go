@@ -0,0 +1,65 @@
+package integresql
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// RequestInfo describes a single request sent to the manager. It never includes request headers,
+// so credentials passed via the Authorization header are never exposed.
+type RequestInfo struct {
+	Method     string
+	URL        string        // resolved URL the request was sent to, stripped of any user info
+	StatusCode int           // 0 if no response was received
+	Duration   time.Duration // time until the response headers were received
+	Err        error         // error sending the request, if any
+	RequestID  string        // ID sent via the X-Request-ID header, see WithRequestID
+}
+
+// Logger is invoked after each request sent to the manager, including retried attempts.
+type Logger interface {
+	LogRequest(ctx context.Context, info RequestInfo)
+}
+
+// LoggerFunc allows using an ordinary function as a Logger.
+type LoggerFunc func(ctx context.Context, info RequestInfo)
+
+func (f LoggerFunc) LogRequest(ctx context.Context, info RequestInfo) {
+	f(ctx, info)
+}
+
+// Metrics records each request sent to the manager, including retried attempts, see pkg/integresqlprom.
+type Metrics interface {
+	ObserveRequest(op Operation, info RequestInfo)
+}
+
+func (c *Client) logRequest(req *http.Request, resp *http.Response, start time.Time, err error) {
+	if c.config.Logger == nil && c.config.Metrics == nil {
+		return
+	}
+
+	u := *req.URL
+	u.User = nil
+
+	info := RequestInfo{
+		Method:    req.Method,
+		URL:       u.String(),
+		Duration:  time.Since(start),
+		Err:       err,
+		RequestID: req.Header.Get(requestIDHeader),
+	}
+
+	if resp != nil {
+		info.StatusCode = resp.StatusCode
+	}
+
+	if c.config.Logger != nil {
+		c.config.Logger.LogRequest(req.Context(), info)
+	}
+
+	if c.config.Metrics != nil {
+		op, _ := OperationFromContext(req.Context())
+		c.config.Metrics.ObserveRequest(op, info)
+	}
+}
@@ -0,0 +1,56 @@
+package integresql
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestClientLogger(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu    sync.Mutex
+		infos []RequestInfo
+	)
+
+	logger := LoggerFunc(func(ctx context.Context, info RequestInfo) {
+		mu.Lock()
+		defer mu.Unlock()
+		infos = append(infos, info)
+	})
+
+	c := newStubClientWithConfig(t, ClientConfig{Logger: logger, AuthToken: "s3cret"}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	srvURL := c.BaseURL().String()
+
+	if err := c.FinalizeTemplate(context.Background(), "hash"); err != nil {
+		t.Fatalf("failed to finalize template: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(infos) != 1 {
+		t.Fatalf("invalid number of logged requests, got %d, want %d", len(infos), 1)
+	}
+
+	info := infos[0]
+	if info.Method != http.MethodPut {
+		t.Errorf("invalid method, got %q, want %q", info.Method, http.MethodPut)
+	}
+	if want := srvURL + "/templates/hash"; info.URL != want {
+		t.Errorf("invalid URL, got %q, want %q", info.URL, want)
+	}
+	if info.StatusCode != http.StatusNoContent {
+		t.Errorf("invalid status code, got %d, want %d", info.StatusCode, http.StatusNoContent)
+	}
+	if info.Duration <= 0 {
+		t.Errorf("invalid duration, got %v", info.Duration)
+	}
+	if info.Err != nil {
+		t.Errorf("invalid error, got %v, want <nil>", info.Err)
+	}
+}
@@ -0,0 +1,88 @@
+package integresql
+
+import (
+	"net/http"
+	"time"
+)
+
+// Option configures a client created via NewClientWithOptions.
+type Option func(o *options)
+
+type options struct {
+	config     ClientConfig
+	httpClient *http.Client
+	timeout    time.Duration
+}
+
+// WithBaseURL sets the base URL of the IntegreSQL manager, e.g. http://127.0.0.1:5000/api
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) {
+		o.config.BaseURL = baseURL
+	}
+}
+
+// WithAPIVersion sets the API version of the IntegreSQL manager, e.g. v1
+func WithAPIVersion(apiVersion string) Option {
+	return func(o *options) {
+		o.config.APIVersion = apiVersion
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to send requests to the manager.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// WithTimeout sets the timeout of the HTTP client used to send requests to the manager.
+// A client passed via WithHTTPClient is copied instead of being modified.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.config.Timeout = timeout
+		o.timeout = timeout
+	}
+}
+
+// WithTracer sets the tracer starting a span for each operation sent to the manager.
+func WithTracer(tracer Tracer) Option {
+	return func(o *options) {
+		o.config.Tracer = tracer
+	}
+}
+
+// WithMetrics sets the metrics recorded for each request sent to the manager.
+func WithMetrics(metrics Metrics) Option {
+	return func(o *options) {
+		o.config.Metrics = metrics
+	}
+}
+
+// NewClientWithOptions creates a new client based on DefaultClientConfigFromEnv, applying the given options in order.
+func NewClientWithOptions(opts ...Option) (*Client, error) {
+	o := &options{
+		config: DefaultClientConfigFromEnv(),
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	c, err := NewClient(o.config)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.httpClient != nil {
+		client := o.httpClient
+		if o.timeout != 0 {
+			copied := *client
+			copied.Timeout = o.timeout
+			client = &copied
+		}
+
+		c.SetClient(client)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,165 @@
+package integresql
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewClientWithOptions(t *testing.T) {
+	t.Parallel()
+
+	httpClient := &http.Client{}
+
+	c, err := NewClientWithOptions(
+		WithBaseURL("http://127.0.0.1:5000/api"),
+		WithAPIVersion("v2"),
+		WithHTTPClient(httpClient),
+		WithTimeout(5*time.Second),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	if got, want := c.baseURL.String(), "http://127.0.0.1:5000/api/v2"; got != want {
+		t.Errorf("invalid base URL, got %q, want %q", got, want)
+	}
+
+	if c.client.Timeout != 5*time.Second {
+		t.Errorf("invalid timeout, got %v, want %v", c.client.Timeout, 5*time.Second)
+	}
+
+	if httpClient.Timeout != 0 {
+		t.Errorf("provided HTTP client was modified, got timeout %v, want %v", httpClient.Timeout, 0)
+	}
+}
+
+func TestNewClientWithOptionsDefaults(t *testing.T) {
+	t.Parallel()
+
+	c, err := NewClientWithOptions()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	defaultConfig := DefaultClientConfigFromEnv()
+	if c.config.BaseURL != defaultConfig.BaseURL {
+		t.Errorf("invalid base URL, got %q, want %q", c.config.BaseURL, defaultConfig.BaseURL)
+	}
+
+	if c.config.APIVersion != defaultConfig.APIVersion {
+		t.Errorf("invalid API version, got %q, want %q", c.config.APIVersion, defaultConfig.APIVersion)
+	}
+}
+
+func TestNewClientTimeout(t *testing.T) {
+	t.Parallel()
+
+	c, err := NewClient(ClientConfig{})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	if want := DefaultClientConfigFromEnv().Timeout; c.client.Timeout != want {
+		t.Errorf("invalid default timeout, got %v, want %v", c.client.Timeout, want)
+	}
+
+	c, err = NewClient(ClientConfig{Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	if c.client.Timeout != time.Second {
+		t.Errorf("invalid timeout, got %v, want %v", c.client.Timeout, time.Second)
+	}
+
+	override := &http.Client{Timeout: 2 * time.Second}
+	c.SetClient(override)
+
+	if c.HTTPClient() != override {
+		t.Error("SetClient did not override the HTTP client")
+	}
+
+	// the configured timeout is preserved if the HTTP client passed has none
+	override = &http.Client{Transport: http.DefaultTransport}
+	c.SetClient(override)
+
+	if got := c.HTTPClient(); got.Timeout != time.Second || got.Transport != http.DefaultTransport {
+		t.Errorf("invalid HTTP client, got timeout %v, want %v and the passed transport", got.Timeout, time.Second)
+	}
+
+	if override.Timeout != 0 {
+		t.Errorf("provided HTTP client was modified, got timeout %v, want %v", override.Timeout, 0)
+	}
+}
+
+func TestNewClientTLSConfig(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+
+	c, err := NewClient(ClientConfig{BaseURL: srv.URL, APIVersion: "v1"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	if _, err := c.IsReady(context.Background()); err == nil {
+		t.Error("request to server with untrusted certificate should have failed")
+	}
+
+	c, err = NewClient(ClientConfig{BaseURL: srv.URL, APIVersion: "v1", TLSConfig: &tls.Config{RootCAs: pool}})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	ready, err := c.IsReady(context.Background())
+	if err != nil {
+		t.Fatalf("failed to query health with custom TLS config: %v", err)
+	}
+
+	if !ready {
+		t.Error("invalid ready state, got false, want true")
+	}
+}
+
+func TestNewClientProxy(t *testing.T) {
+	t.Parallel()
+
+	var proxied int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&proxied, 1)
+		if want := "http://integresql.invalid:5000/api/v1/admin/healthz"; r.URL.String() != want {
+			t.Errorf("invalid proxied URL, got %q, want %q", r.URL.String(), want)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	c, err := NewClient(ClientConfig{BaseURL: "http://integresql.invalid:5000/api", APIVersion: "v1", Proxy: proxy.URL})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	if _, err := c.IsReady(context.Background()); err != nil {
+		t.Fatalf("failed to query health via proxy: %v", err)
+	}
+
+	if n := atomic.LoadInt32(&proxied); n != 1 {
+		t.Errorf("invalid number of proxied requests, got %d, want %d", n, 1)
+	}
+
+	if _, err := NewClient(ClientConfig{BaseURL: "http://integresql:5000/api", APIVersion: "v1", Proxy: "http://proxy:port"}); err == nil {
+		t.Error("creating a client with an invalid proxy URL should have failed")
+	}
+}
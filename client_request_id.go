@@ -0,0 +1,34 @@
+package integresql
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// requestIDHeader is the header carrying the request ID, allowing to correlate client calls with the manager's logs.
+const requestIDHeader = "X-Request-ID"
+
+type requestIDContextKey struct{}
+
+// WithRequestID returns a copy of ctx carrying id, which is sent as X-Request-ID header with all requests issued
+// by a client call using the returned context. If no request ID was set, a random one is generated for each call,
+// which is only reported via Event.RequestID, RequestInfo.RequestID and APIError.RequestID. Callers needing to know
+// the request ID upfront, e.g. to log it alongside their own output, have to pass their own (see NewRequestID).
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID set via WithRequestID (or generated by the client), if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDContextKey{}).(string)
+	return id, ok && len(id) > 0
+}
+
+// NewRequestID generates a random request ID, e.g. to be passed to WithRequestID.
+func NewRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,134 @@
+package integresql
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestClientRequestID(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		received []string
+		logged   []string
+	)
+
+	config := ClientConfig{
+		MaxRetries:   1,
+		RetryBackoff: 1,
+		Logger: LoggerFunc(func(ctx context.Context, info RequestInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			logged = append(logged, info.RequestID)
+		}),
+	}
+
+	c := newStubClientWithConfig(t, config, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = append(received, r.Header.Get("X-Request-ID"))
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	ctx := WithRequestID(context.Background(), "request-1")
+
+	if err := c.DiscardTemplate(ctx, "hash"); !errors.Is(err, ErrManagerNotReady) {
+		t.Errorf("invalid error, got %v, want %v", err, ErrManagerNotReady)
+	}
+
+	// retried attempts share the request ID of the call
+	if err := c.FinalizeTemplate(context.Background(), "hash"); !errors.Is(err, ErrManagerNotReady) {
+		t.Errorf("invalid error, got %v, want %v", err, ErrManagerNotReady)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(received) != 4 {
+		t.Fatalf("invalid number of requests, got %d, want %d", len(received), 4)
+	}
+
+	for i, want := range []string{"request-1", "request-1"} {
+		if received[i] != want {
+			t.Errorf("invalid request ID of request %d, got %q, want %q", i, received[i], want)
+		}
+	}
+
+	if len(received[2]) == 0 || received[2] == "request-1" || received[2] != received[3] {
+		t.Errorf("invalid generated request IDs, got %q and %q, want the same new ID", received[2], received[3])
+	}
+
+	for i := range received {
+		if logged[i] != received[i] {
+			t.Errorf("invalid logged request ID of request %d, got %q, want %q", i, logged[i], received[i])
+		}
+	}
+}
+
+func TestClientRequestIDAPIError(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	ctx := WithRequestID(context.Background(), "request-2")
+
+	var apiErr *APIError
+	if err := c.DiscardTemplate(ctx, "hash"); !errors.As(err, &apiErr) {
+		t.Fatalf("invalid error, got %v, want *APIError", err)
+	}
+
+	if apiErr.RequestID != "request-2" {
+		t.Errorf("invalid request ID, got %q, want %q", apiErr.RequestID, "request-2")
+	}
+}
+
+func TestClientRequestIDEvent(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		received string
+	)
+
+	events := make(chan Event, 1)
+	c := newStubClientWithConfig(t, ClientConfig{Events: events}, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = r.Header.Get("X-Request-ID")
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if err := c.DiscardTemplate(context.Background(), "hash"); err != nil {
+		t.Fatalf("failed to discard template: %v", err)
+	}
+
+	event := <-events
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(event.RequestID) == 0 || event.RequestID != received {
+		t.Errorf("invalid request ID, got %q, want generated request ID %q", event.RequestID, received)
+	}
+}
+
+func TestNewRequestID(t *testing.T) {
+	t.Parallel()
+
+	a, b := NewRequestID(), NewRequestID()
+	if len(a) != 32 {
+		t.Errorf("invalid request ID length, got %d, want %d", len(a), 32)
+	}
+
+	if a == b {
+		t.Errorf("invalid request IDs, got %q twice, want distinct IDs", a)
+	}
+}
@@ -0,0 +1,42 @@
+package integresql
+
+import (
+	"context"
+
+	"github.com/allaboutapps/integresql-client-go/pkg/models"
+)
+
+// ConnectionRewriteFunc rewrites the database config of a template or test database returned by the manager, e.g.
+// to replace the host reported by the manager with one reachable from where the tests run.
+type ConnectionRewriteFunc func(config models.DatabaseConfig) models.DatabaseConfig
+
+type connectionRewriteContextKey struct{}
+
+// WithConnectionRewrite returns a copy of ctx carrying fn, which replaces the client-level rewrite (OverrideHost,
+// OverridePort and ConnectionRewrite) for all database configs returned by client calls using the returned context.
+// This allows to select the rewrite once per environment (e.g. CI vs local) instead of branching in every test.
+func WithConnectionRewrite(ctx context.Context, fn ConnectionRewriteFunc) context.Context {
+	return context.WithValue(ctx, connectionRewriteContextKey{}, fn)
+}
+
+// rewriteDatabaseConfig applies the rewrite set via WithConnectionRewrite if any, or the client-level rewrite
+// otherwise, to the database config returned by the manager.
+func (c *Client) rewriteDatabaseConfig(ctx context.Context, config models.DatabaseConfig) models.DatabaseConfig {
+	if fn, ok := ctx.Value(connectionRewriteContextKey{}).(ConnectionRewriteFunc); ok && fn != nil {
+		return fn(config)
+	}
+
+	if len(c.config.OverrideHost) > 0 {
+		config.Host = c.config.OverrideHost
+	}
+
+	if c.config.OverridePort > 0 {
+		config.Port = models.DatabasePort(c.config.OverridePort)
+	}
+
+	if c.config.ConnectionRewrite != nil {
+		config = c.config.ConnectionRewrite(config)
+	}
+
+	return config
+}
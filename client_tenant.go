@@ -0,0 +1,20 @@
+package integresql
+
+import "context"
+
+// tenantHeader is the header selecting the tenant's pool on multi-tenant managers.
+const tenantHeader = "X-Tenant"
+
+type tenantContextKey struct{}
+
+// WithTenant returns a copy of ctx carrying tenant, which is sent as X-Tenant header with all requests issued by a
+// client call using the returned context. This allows a single client to serve multiple tenants.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant set via WithTenant, if any.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantContextKey{}).(string)
+	return tenant, ok && len(tenant) > 0
+}
@@ -0,0 +1,58 @@
+package integresql
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestClientTenant(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		received []string
+		present  []bool
+	)
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		_, ok := r.Header["X-Tenant"]
+		received = append(received, r.Header.Get("X-Tenant"))
+		present = append(present, ok)
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if err := c.DiscardTemplate(WithTenant(context.Background(), "tenant-a"), "hash"); err != nil {
+		t.Fatalf("failed to discard template: %v", err)
+	}
+
+	if err := c.DiscardTemplate(WithTenant(context.Background(), "tenant-b"), "hash"); err != nil {
+		t.Fatalf("failed to discard template: %v", err)
+	}
+
+	if err := c.DiscardTemplate(context.Background(), "hash"); err != nil {
+		t.Fatalf("failed to discard template: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(received) != 3 {
+		t.Fatalf("invalid number of requests, got %d, want %d", len(received), 3)
+	}
+
+	for i, want := range []string{"tenant-a", "tenant-b"} {
+		if received[i] != want {
+			t.Errorf("invalid tenant of request %d, got %q, want %q", i, received[i], want)
+		}
+	}
+
+	if present[2] {
+		t.Errorf("invalid tenant header without tenant in context, got %q, want none", received[2])
+	}
+}
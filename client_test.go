@@ -1,13 +1,28 @@
 package integresql
 
 import (
+	"compress/gzip"
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	_ "github.com/lib/pq"
+
+	"github.com/allaboutapps/integresql-client-go/pkg/models"
 )
 
 func TestClientInitializeTemplate(t *testing.T) {
@@ -202,6 +217,135 @@ func TestClientReturnTestDatabase(t *testing.T) {
 	}
 }
 
+func TestClientRecreateTestDatabase(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := DefaultClientFromEnv()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	if err := c.ResetAllTracking(ctx); err != nil {
+		t.Fatalf("failed to reset all test pool tracking: %v", err)
+	}
+
+	hash := "hashinghash6"
+
+	if _, err := c.InitializeTemplate(ctx, hash); err != nil {
+		t.Fatalf("failed to initialize template: %v", err)
+	}
+
+	if err := c.FinalizeTemplate(ctx, hash); err != nil {
+		t.Fatalf("failed to finalize template: %v", err)
+	}
+
+	test, err := c.GetTestDatabase(ctx, hash)
+	if err != nil {
+		t.Fatalf("failed to get test database: %v", err)
+	}
+
+	if err := c.RecreateTestDatabase(ctx, hash, test.ID); err != nil {
+		t.Fatalf("failed to recreate test database: %v", err)
+	}
+
+	test2, err := c.GetTestDatabase(ctx, hash)
+	if err != nil {
+		t.Fatalf("failed to get second test database: %v", err)
+	}
+
+	if test2.TemplateHash != hash {
+		t.Errorf("test database has invalid second template hash, got %q, want %q", test2.TemplateHash, hash)
+	}
+
+	if err := c.RecreateTestDatabase(ctx, hash, 9999); err == nil {
+		t.Error("recreating unknown test database should have failed")
+	}
+}
+
+func TestClientUnlockTestDatabase(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := DefaultClientFromEnv()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	if err := c.ResetAllTracking(ctx); err != nil {
+		t.Fatalf("failed to reset all test pool tracking: %v", err)
+	}
+
+	hash := "hashinghash7"
+
+	if _, err := c.InitializeTemplate(ctx, hash); err != nil {
+		t.Fatalf("failed to initialize template: %v", err)
+	}
+
+	if err := c.FinalizeTemplate(ctx, hash); err != nil {
+		t.Fatalf("failed to finalize template: %v", err)
+	}
+
+	test, err := c.GetTestDatabase(ctx, hash)
+	if err != nil {
+		t.Fatalf("failed to get test database: %v", err)
+	}
+
+	if err := c.UnlockTestDatabase(ctx, hash, test.ID); err != nil {
+		t.Fatalf("failed to unlock test database: %v", err)
+	}
+
+	test2, err := c.GetTestDatabase(ctx, hash)
+	if err != nil {
+		t.Fatalf("failed to get second test database: %v", err)
+	}
+
+	if test2.ID != test.ID {
+		t.Errorf("received invalid test database, want %d, got %d", test.ID, test2.ID)
+	}
+}
+
+func TestClientWithTestDatabase(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := DefaultClientFromEnv()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	if err := c.ResetAllTracking(ctx); err != nil {
+		t.Fatalf("failed to reset all test pool tracking: %v", err)
+	}
+
+	hash := "hashinghash8"
+
+	if err := c.SetupTemplateWithDBClient(ctx, hash, func(db *sql.DB) error {
+		return populateTemplateDB(ctx, db)
+	}); err != nil {
+		t.Fatalf("failed to setup template database for hash %q: %v", hash, err)
+	}
+
+	if err := c.WithTestDatabase(ctx, hash, func(db *sql.DB) error {
+		var count int
+		if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM pilots").Scan(&count); err != nil {
+			return err
+		}
+
+		if count != 2 {
+			t.Errorf("invalid number of pilots, got %d, want %d", count, 2)
+		}
+
+		return nil
+	}); err != nil {
+		t.Fatalf("failed to use test database: %v", err)
+	}
+
+	errFailed := errors.New("test failed")
+	if err := c.WithTestDatabase(ctx, hash, func(db *sql.DB) error {
+		return errFailed
+	}); !errors.Is(err, errFailed) {
+		t.Errorf("invalid error, got %v, want %v", err, errFailed)
+	}
+}
+
 func populateTemplateDB(ctx context.Context, db *sql.DB) error {
 	if _, err := db.ExecContext(ctx, `
 		CREATE EXTENSION "uuid-ossp";
@@ -405,3 +549,2648 @@ func TestSetupTemplateWithDBClientFailingSetupCodeAndReinitialize(t *testing.T)
 		t.Fatalf("Failed to resetup template database for hash %q: %v", hash, err)
 	}
 }
+
+func newStubClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	return newStubClientWithConfig(t, ClientConfig{}, handler)
+}
+
+func newStubClientWithConfig(t *testing.T, config ClientConfig, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	config.BaseURL = srv.URL + "/api"
+	config.APIVersion = "v1"
+
+	c, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	return c
+}
+
+func TestClientIsReady(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		status  int
+		want    bool
+		wantErr bool
+	}{
+		{name: "Ready", status: http.StatusOK, want: true},
+		{name: "NotReady", status: http.StatusServiceUnavailable, want: false},
+		{name: "Unexpected", status: http.StatusInternalServerError, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet || r.URL.Path != "/api/v1/admin/healthz" {
+					t.Errorf("invalid request, got %s %s", r.Method, r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+			})
+
+			ready, err := c.IsReady(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("invalid error, got %v, want error %v", err, tt.wantErr)
+			}
+
+			if ready != tt.want {
+				t.Errorf("invalid ready state, got %v, want %v", ready, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientHealth(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    models.HealthStatus
+		wantErr bool
+	}{
+		{
+			name:   "Ready",
+			status: http.StatusOK,
+			body:   `{"version":"v1.1.0","ready":true,"templates":2,"pool":{"ready":8,"dirty":1,"inUse":3}}`,
+			want:   models.HealthStatus{Version: "v1.1.0", Ready: true, Templates: 2, Pool: models.TemplatePoolStats{Ready: 8, Dirty: 1, InUse: 3}},
+		},
+		{
+			name:   "NotReady",
+			status: http.StatusServiceUnavailable,
+			body:   `{"version":"v1.1.0","ready":false}`,
+			want:   models.HealthStatus{Version: "v1.1.0"},
+		},
+		{name: "EmptyBody", status: http.StatusOK, want: models.HealthStatus{Ready: true}},
+		{name: "Unexpected", status: http.StatusInternalServerError, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet || r.URL.Path != "/api/v1/admin/healthz" {
+					t.Errorf("invalid request, got %s %s", r.Method, r.URL.Path)
+				}
+				if len(tt.body) > 0 {
+					w.Header().Set("Content-Type", "application/json")
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			})
+
+			status, err := c.Health(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("invalid error, got %v, want error %v", err, tt.wantErr)
+			}
+
+			if status != tt.want {
+				t.Errorf("invalid health status, got %+v, want %+v", status, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientIsReadyContextDeadline(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := c.IsReady(ctx); err == nil {
+		t.Error("health probe should have failed after context deadline")
+	}
+}
+
+func TestClientContextCancelledMidRequest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		ctx    func() (context.Context, context.CancelFunc)
+		cancel bool
+		want   error
+	}{
+		{
+			name: "Canceled",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			cancel: true,
+			want:   context.Canceled,
+		},
+		{
+			name: "DeadlineExceeded",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 50*time.Millisecond)
+			},
+			want: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			received := make(chan struct{})
+			c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+				close(received)
+				<-r.Context().Done()
+			})
+
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			if tt.cancel {
+				go func() {
+					<-received
+					cancel()
+				}()
+			}
+
+			_, err := c.GetTestDatabase(ctx, "hash")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("invalid error, got %v, want %v", err, tt.want)
+			}
+
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				t.Errorf("invalid error, got %v, want the context's error without *url.Error", err)
+			}
+		})
+	}
+}
+
+func TestClientWaitForReady(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := c.WaitForReady(ctx, 10*time.Millisecond); err != nil {
+		t.Fatalf("failed to wait for ready: %v", err)
+	}
+
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Errorf("invalid number of health probes, got %d, want %d", n, 3)
+	}
+}
+
+func TestClientWaitForReadyContextCancelled(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := c.WaitForReady(ctx, 10*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("invalid error, got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestClientWaitForReadyUnexpectedStatus(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := c.WaitForReady(ctx, 10*time.Millisecond)
+	if err == nil || errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("invalid error, got %v, want unexpected status error", err)
+	}
+}
+
+func TestClientRetryServiceUnavailable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		status    int
+		call      func(c *Client) error
+		wantCalls int32
+		wantErr   error
+	}{
+		{
+			name:   "IdempotentRecovers",
+			status: http.StatusServiceUnavailable,
+			call: func(c *Client) error {
+				return c.ReturnTestDatabase(context.Background(), "hash", 1)
+			},
+			wantCalls: 3,
+		},
+		{
+			name:   "IdempotentExhausted",
+			status: http.StatusServiceUnavailable,
+			call: func(c *Client) error {
+				return c.FinalizeTemplate(context.Background(), "hash")
+			},
+			wantCalls: 4,
+			wantErr:   ErrManagerNotReady,
+		},
+		{
+			name:   "NonIdempotentRecovers",
+			status: http.StatusServiceUnavailable,
+			call: func(c *Client) error {
+				return c.RecreateTestDatabase(context.Background(), "hash", 1)
+			},
+			wantCalls: 2,
+		},
+		{
+			name:   "NonIdempotentExhausted",
+			status: http.StatusServiceUnavailable,
+			call: func(c *Client) error {
+				return c.RecreateTestDatabase(context.Background(), "hash", 1)
+			},
+			wantCalls: 4,
+			wantErr:   ErrManagerNotReady,
+		},
+		{
+			name:   "ClientError",
+			status: http.StatusNotFound,
+			call: func(c *Client) error {
+				return c.DiscardTemplate(context.Background(), "hash")
+			},
+			wantCalls: 1,
+			wantErr:   ErrTemplateNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls int32
+			c := newStubClientWithConfig(t, ClientConfig{MaxRetries: 3, RetryBackoff: time.Millisecond}, func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&calls, 1)
+				if tt.wantErr == nil && n == tt.wantCalls {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				w.WriteHeader(tt.status)
+			})
+
+			if err := tt.call(c); !errors.Is(err, tt.wantErr) {
+				t.Errorf("invalid error, got %v, want %v", err, tt.wantErr)
+			}
+
+			if n := atomic.LoadInt32(&calls); n != tt.wantCalls {
+				t.Errorf("invalid number of requests, got %d, want %d", n, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestClientRetryContextCancelled(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClientWithConfig(t, ClientConfig{MaxRetries: 10, RetryBackoff: time.Second}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := c.FinalizeTemplate(ctx, "hash"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("invalid error, got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestClientRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+	c := newStubClientWithConfig(t, ClientConfig{MaxRetries: 3, RetryBackoff: time.Hour}, func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.Header().Set("Retry-After", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 3:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+
+	// the configured backoff of an hour is replaced by the delay suggested by the manager
+	if err := c.FinalizeTemplate(context.Background(), "hash"); err != nil {
+		t.Fatalf("failed to finalize template: %v", err)
+	}
+
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Errorf("invalid number of requests, got %d, want %d", n, 3)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// the suggested delay exceeds the deadline, the error is returned right away instead of waiting for the deadline
+	err := c.FinalizeTemplate(ctx, "hash")
+	if !errors.Is(err, ErrManagerNotReady) {
+		t.Fatalf("invalid error, got %v, want %v", err, ErrManagerNotReady)
+	}
+
+	var notReadyErr *NotReadyError
+	if !errors.As(err, &notReadyErr) {
+		t.Fatalf("invalid error, got %v, want *NotReadyError", err)
+	}
+
+	if notReadyErr.RetryAfter != 120*time.Second {
+		t.Errorf("invalid retry after, got %v, want %v", notReadyErr.RetryAfter, 120*time.Second)
+	}
+
+	if want := `finalize template "hash": manager not ready (retry after 2m0s)`; err.Error() != want {
+		t.Errorf("invalid error message, got %q, want %q", err.Error(), want)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		header string
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "Missing", header: "", want: 0, wantOK: false},
+		{name: "Seconds", header: "3", want: 3 * time.Second, wantOK: true},
+		{name: "NegativeSeconds", header: "-1", want: 0, wantOK: false},
+		{name: "PastDate", header: "Wed, 21 Oct 2015 07:28:00 GMT", want: 0, wantOK: true},
+		{name: "Invalid", header: "soon", want: 0, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			resp := &http.Response{Header: http.Header{}}
+			if len(tt.header) > 0 {
+				resp.Header.Set("Retry-After", tt.header)
+			}
+
+			got, ok := parseRetryAfter(resp)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("invalid retry after, got %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestClientAuthToken(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClientWithConfig(t, ClientConfig{AuthToken: "t0ken"}, func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Header.Get("Authorization"), "Bearer t0ken"; got != want {
+			t.Errorf("invalid Authorization header, got %q, want %q", got, want)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	if _, err := c.IsReady(context.Background()); err != nil {
+		t.Fatalf("failed to query health: %v", err)
+	}
+}
+
+func TestClientBasicAuth(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		config ClientConfig
+		want   string
+	}{
+		{
+			name:   "BasicAuth",
+			config: ClientConfig{Username: "user", Password: "pass"},
+			want:   "Basic dXNlcjpwYXNz",
+		},
+		{
+			name:   "BearerPrecedence",
+			config: ClientConfig{AuthToken: "t0ken", Username: "user", Password: "pass"},
+			want:   "Bearer t0ken",
+		},
+		{
+			name:   "UsernameOnly",
+			config: ClientConfig{Username: "user"},
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newStubClientWithConfig(t, tt.config, func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("Authorization"); got != tt.want {
+					t.Errorf("invalid Authorization header, got %q, want %q", got, tt.want)
+				}
+				w.WriteHeader(http.StatusOK)
+			})
+
+			if _, err := c.IsReady(context.Background()); err != nil {
+				t.Fatalf("failed to query health: %v", err)
+			}
+		})
+	}
+}
+
+func TestClientDefaultHeaders(t *testing.T) {
+	t.Parallel()
+
+	config := ClientConfig{
+		Headers: http.Header{
+			"X-Team": []string{"payments"},
+		},
+	}
+
+	c := newStubClientWithConfig(t, config, func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Header.Get("X-Team"), "payments"; got != want {
+			t.Errorf("invalid X-Team header, got %q, want %q", got, want)
+		}
+		if got, want := r.Header.Get("X-Trace"), "abc"; got != want {
+			t.Errorf("invalid X-Trace header, got %q, want %q", got, want)
+		}
+		if got, want := r.Header.Get("Accept"), "application/json"; got != want {
+			t.Errorf("invalid Accept header, got %q, want %q", got, want)
+		}
+		if got, want := r.Header.Get("Content-Type"), "application/json; charset=UTF-8"; got != want {
+			t.Errorf("invalid Content-Type header, got %q, want %q", got, want)
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("{}"))
+	})
+
+	c.SetDefaultHeader("X-Trace", "abc")
+
+	if _, err := c.InitializeTemplate(context.Background(), "hash"); err != nil {
+		t.Fatalf("failed to initialize template: %v", err)
+	}
+}
+
+func TestClientUserAgent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		config ClientConfig
+		want   string
+	}{
+		{
+			name:   "Default",
+			config: ClientConfig{},
+			want:   "integresql-client-go/",
+		},
+		{
+			name:   "Custom",
+			config: ClientConfig{UserAgent: "payments-service/1.0"},
+			want:   "payments-service/1.0",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newStubClientWithConfig(t, tt.config, func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("User-Agent"); !strings.HasPrefix(got, tt.want) {
+					t.Errorf("invalid User-Agent header, got %q, want %q", got, tt.want)
+				}
+				w.WriteHeader(http.StatusOK)
+			})
+
+			if _, err := c.IsReady(context.Background()); err != nil {
+				t.Fatalf("failed to query health: %v", err)
+			}
+		})
+	}
+}
+
+func TestClientDefaultHeadersOverride(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Header.Get("Accept"), "application/vnd.integresql+json"; got != want {
+			t.Errorf("invalid Accept header, got %q, want %q", got, want)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	c.SetDefaultHeader("Accept", "application/vnd.integresql+json")
+
+	if _, err := c.IsReady(context.Background()); err != nil {
+		t.Fatalf("failed to query health: %v", err)
+	}
+}
+
+func TestClientClone(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		received []string
+	)
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = append(received, r.Header.Get("X-Tenant"))
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusOK)
+	})
+
+	c.SetDefaultHeader("X-Tenant", "a")
+
+	clone := c.Clone()
+	clone.SetDefaultHeader("X-Tenant", "b")
+
+	if clone.client != c.client {
+		t.Error("invalid HTTP client of clone, got a new client, want the shared one")
+	}
+
+	if clone.BaseURL().String() != c.BaseURL().String() {
+		t.Errorf("invalid base URL of clone, got %q, want %q", clone.BaseURL().String(), c.BaseURL().String())
+	}
+
+	if _, err := c.IsReady(context.Background()); err != nil {
+		t.Fatalf("failed to query health: %v", err)
+	}
+
+	if _, err := clone.IsReady(context.Background()); err != nil {
+		t.Fatalf("failed to query health via clone: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for i, want := range []string{"a", "b"} {
+		if received[i] != want {
+			t.Errorf("invalid X-Tenant header of request %d, got %q, want %q", i, received[i], want)
+		}
+	}
+}
+
+func TestClientConcurrentConfiguration(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 10; j++ {
+				c.SetDefaultHeader("X-Worker", fmt.Sprintf("%d-%d", i, j))
+				c.SetClient(&http.Client{Timeout: time.Second})
+			}
+		}(i)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 10; j++ {
+				if _, err := c.IsReady(ctx); err != nil {
+					t.Errorf("failed to query health: %v", err)
+				}
+				_ = c.Clone()
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestClientAPIError(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"message":"template is busy"}`))
+	})
+
+	_, err := c.InitializeTemplate(context.Background(), "hash")
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("invalid error, got %v, want *APIError", err)
+	}
+
+	if apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("invalid status code, got %d, want %d", apiErr.StatusCode, http.StatusConflict)
+	}
+
+	if want := `{"message":"template is busy"}`; apiErr.Body != want {
+		t.Errorf("invalid body, got %q, want %q", apiErr.Body, want)
+	}
+
+	if !errors.Is(err, ErrUnexpectedStatus) {
+		t.Errorf("invalid error, got %v, want %v", err, ErrUnexpectedStatus)
+	}
+}
+
+func TestClientSentinelErrors(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("not found"))
+	})
+
+	if _, err := c.GetTestDatabase(context.Background(), "hash"); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("invalid error, got %v, want %v", err, ErrTemplateNotFound)
+	}
+
+	if _, err := c.GetTestDatabase(context.Background(), "hash"); errors.Is(err, ErrUnexpectedStatus) {
+		t.Errorf("invalid error, got %v, want it not to match %v", err, ErrUnexpectedStatus)
+	}
+}
+
+func TestClientWrappedErrors(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	_, err := c.InitializeTemplate(context.Background(), "hashinghash")
+	if !errors.Is(err, ErrManagerNotReady) {
+		t.Fatalf("invalid error, got %v, want %v", err, ErrManagerNotReady)
+	}
+
+	if want := `initialize template "hashinghash": manager not ready`; err.Error() != want {
+		t.Errorf("invalid error message, got %q, want %q", err.Error(), want)
+	}
+}
+
+func TestClientBaseURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		config ClientConfig
+		want   string
+	}{
+		{
+			name:   "Default",
+			config: ClientConfig{BaseURL: "http://integresql:5000/api", APIVersion: "v1"},
+			want:   "http://integresql:5000/api/v1",
+		},
+		{
+			name:   "TrailingSlash",
+			config: ClientConfig{BaseURL: "http://integresql:5000/api/", APIVersion: "v2"},
+			want:   "http://integresql:5000/api/v2",
+		},
+		{
+			name:   "NoPath",
+			config: ClientConfig{BaseURL: "http://127.0.0.1:5000", APIVersion: "v1"},
+			want:   "http://127.0.0.1:5000/v1",
+		},
+		{
+			name:   "VersionSuffix",
+			config: ClientConfig{BaseURL: "http://integresql:5000/api/v1", APIVersion: "v1"},
+			want:   "http://integresql:5000/api/v1",
+		},
+		{
+			name:   "VersionSuffixTrailingSlash",
+			config: ClientConfig{BaseURL: "http://integresql:5000/api/v1/", APIVersion: "v1"},
+			want:   "http://integresql:5000/api/v1",
+		},
+		{
+			name:   "VersionSuffixNoPath",
+			config: ClientConfig{BaseURL: "http://127.0.0.1:5000/v1", APIVersion: "v1"},
+			want:   "http://127.0.0.1:5000/v1",
+		},
+		{
+			name:   "OtherVersionSuffix",
+			config: ClientConfig{BaseURL: "http://integresql:5000/api/v1", APIVersion: "v2"},
+			want:   "http://integresql:5000/api/v1/v2",
+		},
+		{
+			name:   "PathPrefix",
+			config: ClientConfig{BaseURL: "http://proxy:8080", PathPrefix: "/integresql/api", APIVersion: "v1"},
+			want:   "http://proxy:8080/integresql/api/v1",
+		},
+		{
+			name:   "PathPrefixNoLeadingSlash",
+			config: ClientConfig{BaseURL: "http://proxy:8080/", PathPrefix: "integresql/api/", APIVersion: "v1"},
+			want:   "http://proxy:8080/integresql/api/v1",
+		},
+		{
+			name:   "PathPrefixBasePath",
+			config: ClientConfig{BaseURL: "http://proxy:8080/tools", PathPrefix: "/integresql/api", APIVersion: "v2"},
+			want:   "http://proxy:8080/tools/integresql/api/v2",
+		},
+		{
+			name:   "PathPrefixVersionSuffix",
+			config: ClientConfig{BaseURL: "http://proxy:8080", PathPrefix: "/integresql/api/v1", APIVersion: "v1"},
+			want:   "http://proxy:8080/integresql/api/v1",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := NewClient(tt.config)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			u := c.BaseURL()
+			if u.String() != tt.want {
+				t.Errorf("invalid base URL, got %q, want %q", u.String(), tt.want)
+			}
+
+			u.Path = "/modified"
+			if c.BaseURL().String() != tt.want {
+				t.Error("modifying the returned base URL changed the client's base URL")
+			}
+		})
+	}
+}
+
+func TestClientListTemplates(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/api/v1/admin/templates" {
+			t.Errorf("invalid request, got %s %s", r.Method, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[
+			{"database":{"templateHash":"hash1","config":{"host":"localhost","port":5432,"username":"user","password":"pass","database":"integresql_template_hash1"}}},
+			{"database":{"templateHash":"hash2","config":{"host":"localhost","port":5432,"username":"user","password":"pass","database":"integresql_template_hash2"}}}
+		]`))
+	})
+
+	templates, err := c.ListTemplates(context.Background())
+	if err != nil {
+		t.Fatalf("failed to list templates: %v", err)
+	}
+
+	if len(templates) != 2 {
+		t.Fatalf("invalid number of templates, got %d, want %d", len(templates), 2)
+	}
+
+	if templates[1].TemplateHash != "hash2" {
+		t.Errorf("invalid template hash, got %q, want %q", templates[1].TemplateHash, "hash2")
+	}
+
+	if templates[0].Config.Database != "integresql_template_hash1" {
+		t.Errorf("invalid template database, got %q, want %q", templates[0].Config.Database, "integresql_template_hash1")
+	}
+}
+
+func TestClientGzipResponse(t *testing.T) {
+	t.Parallel()
+
+	writeGzip := func(w http.ResponseWriter, status int, body string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.WriteHeader(status)
+
+		gz := gzip.NewWriter(w)
+		_, _ = gz.Write([]byte(body))
+		_ = gz.Close()
+	}
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Header.Get("Accept-Encoding"), "gzip"; got != want {
+			t.Errorf("invalid Accept-Encoding header, got %q, want %q", got, want)
+		}
+
+		switch r.URL.Path {
+		case "/api/v1/admin/templates":
+			writeGzip(w, http.StatusOK, `[{"database":{"templateHash":"hash1"}},{"database":{"templateHash":"hash2"}}]`)
+		default:
+			writeGzip(w, http.StatusConflict, `{"message":"template is busy"}`)
+		}
+	})
+
+	ctx := context.Background()
+
+	templates, err := c.ListTemplates(ctx)
+	if err != nil {
+		t.Fatalf("failed to list templates: %v", err)
+	}
+
+	if len(templates) != 2 || templates[1].TemplateHash != "hash2" {
+		t.Errorf("invalid templates, got %+v, want %q and %q", templates, "hash1", "hash2")
+	}
+
+	_, err = c.InitializeTemplate(ctx, "hash")
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("invalid error, got %v, want *APIError", err)
+	}
+
+	if apiErr.Message != "template is busy" {
+		t.Errorf("invalid error message, got %q, want %q", apiErr.Message, "template is busy")
+	}
+}
+
+func TestClientCloseContext(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	ctx := context.Background()
+
+	if err := c.CloseContext(ctx); err != nil {
+		t.Fatalf("failed to close client: %v", err)
+	}
+
+	if err := c.CloseContext(ctx); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("invalid error closing client twice, got %v, want %v", err, ErrClientClosed)
+	}
+
+	if _, err := c.IsReady(ctx); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("invalid error using closed client, got %v, want %v", err, ErrClientClosed)
+	}
+}
+
+func TestClientConnectionReuse(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v1/templates" {
+			// invalid JSON, failing to decode halfway through the body
+			_, _ = w.Write([]byte(`{"database":{"templateHash":tru` + strings.Repeat(" ", 1<<20) + `}}`))
+			return
+		}
+		// valid JSON followed by trailing data never read by the decoder
+		_, _ = w.Write([]byte(`{"id":1,"database":{"templateHash":"hash"}}` + strings.Repeat(" ", 1<<20)))
+	}))
+	defer srv.Close()
+
+	var dials int32
+	dialer := &net.Dialer{}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			atomic.AddInt32(&dials, 1)
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}
+	defer transport.CloseIdleConnections()
+
+	c, err := NewClient(ClientConfig{BaseURL: srv.URL + "/api", APIVersion: "v1"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	c.SetClient(&http.Client{Transport: transport})
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		if _, err := c.GetTestDatabase(ctx, "hash"); err != nil {
+			t.Fatalf("failed to get test database: %v", err)
+		}
+
+		if _, err := c.InitializeTemplate(ctx, "hash"); err == nil {
+			t.Fatal("initializing template with invalid response should have failed")
+		}
+	}
+
+	if n := atomic.LoadInt32(&dials); n != 1 {
+		t.Errorf("invalid number of dialed connections, got %d, want %d", n, 1)
+	}
+}
+
+func TestClientAPIErrorIncludesBody(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "Empty",
+			body: "",
+			want: `finalize template "hash": received unexpected HTTP status 400 (400 Bad Request)`,
+		},
+		{
+			name: "JSON",
+			body: `{"message":"invalid hash"}` + "\n",
+			want: `finalize template "hash": received unexpected HTTP status 400 (400 Bad Request): invalid hash`,
+		},
+		{
+			name: "Truncated",
+			body: strings.Repeat("a", maxErrorBodyLength+10),
+			want: `finalize template "hash": received unexpected HTTP status 400 (400 Bad Request): ` + strings.Repeat("a", maxErrorBodyLength) + "...",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(tt.body))
+			})
+
+			err := c.FinalizeTemplate(context.Background(), "hash")
+			if err == nil {
+				t.Fatal("finalizing template should have failed")
+			}
+
+			if err.Error() != tt.want {
+				t.Errorf("invalid error message, got %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestClientGetTestDatabaseRetryPoolExhausted(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		status    int
+		wantCalls int32
+		wantErr   bool
+	}{
+		{name: "PoolExhausted", status: http.StatusTooManyRequests, wantCalls: 3},
+		{name: "TemplateNotFound", status: http.StatusNotFound, wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls int32
+			config := ClientConfig{GetTestDatabaseRetries: 5, GetTestDatabaseBackoff: time.Millisecond}
+			c := newStubClientWithConfig(t, config, func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&calls, 1) < 3 {
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"id":1,"database":{"templateHash":"hash"}}`))
+			})
+
+			_, err := c.GetTestDatabase(context.Background(), "hash")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("invalid error, got %v, want error %v", err, tt.wantErr)
+			}
+
+			if n := atomic.LoadInt32(&calls); n != tt.wantCalls {
+				t.Errorf("invalid number of requests, got %d, want %d", n, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestClientGetTestDatabasePoolExhausted(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+	config := ClientConfig{GetTestDatabaseRetries: 2, GetTestDatabaseBackoff: time.Millisecond}
+	c := newStubClientWithConfig(t, config, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	_, err := c.GetTestDatabase(context.Background(), "hash")
+	if !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("invalid error, got %v, want %v", err, ErrPoolExhausted)
+	}
+
+	if errors.Is(err, ErrManagerNotReady) {
+		t.Errorf("invalid error, got %v, want it not to match %v", err, ErrManagerNotReady)
+	}
+
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Errorf("invalid number of requests, got %d, want %d", n, 3)
+	}
+}
+
+func TestClientReleaseTestDatabase(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		unlock        bool
+		wantRequest   string
+		wantOperation string
+	}{
+		{name: "Recreate", wantRequest: "POST /api/v1/templates/hash/tests/1/recreate", wantOperation: "RecreateTestDatabase"},
+		{name: "Unlock", unlock: true, wantRequest: "DELETE /api/v1/templates/hash/tests/1", wantOperation: "ReturnTestDatabase"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			events := make(chan Event, 1)
+			config := ClientConfig{UnlockOnRelease: tt.unlock, Events: events}
+
+			var request string
+			c := newStubClientWithConfig(t, config, func(w http.ResponseWriter, r *http.Request) {
+				request = r.Method + " " + r.URL.Path
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			if err := c.ReleaseTestDatabase(context.Background(), "hash", 1); err != nil {
+				t.Fatalf("failed to release test database: %v", err)
+			}
+
+			if request != tt.wantRequest {
+				t.Errorf("invalid request, got %q, want %q", request, tt.wantRequest)
+			}
+
+			if event := <-events; event.Operation != tt.wantOperation {
+				t.Errorf("invalid operation, got %q, want %q", event.Operation, tt.wantOperation)
+			}
+		})
+	}
+}
+
+func TestClientRecycleTestDatabase(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		getStatus int
+		wantID    models.TestDatabaseID
+		wantErr   error
+	}{
+		{name: "Success", getStatus: http.StatusOK, wantID: 2},
+		{name: "GetFails", getStatus: http.StatusNotFound, wantErr: ErrTemplateNotFound},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var requests []string
+			c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.Method+" "+r.URL.Path)
+
+				switch r.Method {
+				case http.MethodPost:
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					if tt.getStatus != http.StatusOK {
+						w.WriteHeader(tt.getStatus)
+						return
+					}
+					w.Header().Set("Content-Type", "application/json")
+					_, _ = w.Write([]byte(`{"id":2,"database":{"templateHash":"hash"}}`))
+				}
+			})
+
+			test, err := c.RecycleTestDatabase(context.Background(), "hash", 1)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("invalid error, got %v, want %v", err, tt.wantErr)
+			}
+
+			if err != nil && !strings.Contains(err.Error(), "already been returned") {
+				t.Errorf("invalid error message, got %q, want it to mention the returned test database", err.Error())
+			}
+
+			if test.ID != tt.wantID {
+				t.Errorf("invalid test database ID, got %d, want %d", test.ID, tt.wantID)
+			}
+
+			want := []string{"POST /api/v1/templates/hash/tests/1/recreate", "GET /api/v1/templates/hash/tests"}
+			if len(requests) != len(want) {
+				t.Fatalf("invalid requests, got %v, want %v", requests, want)
+			}
+
+			for i := range want {
+				if requests[i] != want[i] {
+					t.Errorf("invalid request %d, got %q, want %q", i, requests[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestClientWaitForTestDatabase(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+	config := ClientConfig{GetTestDatabaseBackoff: time.Millisecond}
+	c := newStubClientWithConfig(t, config, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/templates/exhausted/tests":
+			w.WriteHeader(http.StatusTooManyRequests)
+		case r.URL.Path == "/api/v1/templates/hash/tests" && atomic.AddInt32(&calls, 1) > 5:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id":1,"database":{"templateHash":"hash"}}`))
+		case r.URL.Path == "/api/v1/templates/hash/tests":
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	ctx := context.Background()
+
+	test, err := c.WaitForTestDatabase(ctx, "hash")
+	if err != nil {
+		t.Fatalf("failed to wait for test database: %v", err)
+	}
+
+	if test.ID != 1 {
+		t.Errorf("invalid test database ID, got %d, want %d", test.ID, 1)
+	}
+
+	if n := atomic.LoadInt32(&calls); n != 6 {
+		t.Errorf("invalid number of requests, got %d, want %d", n, 6)
+	}
+
+	if _, err := c.WaitForTestDatabase(ctx, "unknown"); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("invalid error, got %v, want %v", err, ErrTemplateNotFound)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := c.WaitForTestDatabase(timeoutCtx, "exhausted"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("invalid error, got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestClientGetTemplate(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("invalid method, got %s, want %s", r.Method, http.MethodGet)
+		}
+		switch r.URL.Path {
+		case "/api/v1/templates/hash":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"database":{"templateHash":"hash","config":{"host":"localhost","port":5432,"username":"user","password":"pass","database":"integresql_template_hash"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	ctx := context.Background()
+
+	template, err := c.GetTemplate(ctx, "hash")
+	if err != nil {
+		t.Fatalf("failed to get template: %v", err)
+	}
+
+	if template.TemplateHash != "hash" {
+		t.Errorf("invalid template hash, got %q, want %q", template.TemplateHash, "hash")
+	}
+
+	if template.Config.Database != "integresql_template_hash" {
+		t.Errorf("invalid template database, got %q, want %q", template.Config.Database, "integresql_template_hash")
+	}
+
+	if _, err := c.GetTemplate(ctx, "unknown"); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("invalid error, got %v, want %v", err, ErrTemplateNotFound)
+	}
+}
+
+func TestClientResetAllTrackingErrorMessage(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"message":"failed to reset tracking of template hash"}`))
+	})
+
+	err := c.ResetAllTracking(context.Background())
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("invalid error, got %v, want *APIError", err)
+	}
+
+	if want := "failed to reset tracking of template hash"; apiErr.Message != want {
+		t.Errorf("invalid message, got %q, want %q", apiErr.Message, want)
+	}
+
+	if want := "reset all tracking: received unexpected HTTP status 500 (500 Internal Server Error): failed to reset tracking of template hash"; err.Error() != want {
+		t.Errorf("invalid error message, got %q, want %q", err.Error(), want)
+	}
+}
+
+func TestClientResetAllTrackingNotReady(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	if err := c.ResetAllTracking(context.Background()); !errors.Is(err, ErrManagerNotReady) {
+		t.Errorf("invalid error, got %v, want %v", err, ErrManagerNotReady)
+	}
+}
+
+func TestClientOverrideDatabaseConfig(t *testing.T) {
+	t.Parallel()
+
+	config := ClientConfig{
+		OverrideHost: "localhost",
+		OverridePort: 5433,
+	}
+
+	c := newStubClientWithConfig(t, config, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/templates":
+			_, _ = w.Write([]byte(`{"database":{"templateHash":"hash","config":{"host":"postgres","port":5432,"username":"user","password":"pass","database":"integresql_template_hash"}}}`))
+		case "/api/v1/templates/hash/tests":
+			_, _ = w.Write([]byte(`{"id":1,"database":{"templateHash":"hash","config":{"host":"postgres","port":5432,"username":"user","password":"pass","database":"integresql_test_hash_001"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	ctx := context.Background()
+
+	template, err := c.InitializeTemplate(ctx, "hash")
+	if err != nil {
+		t.Fatalf("failed to initialize template: %v", err)
+	}
+
+	if template.Config.Host != "localhost" || template.Config.Port != 5433 {
+		t.Errorf("invalid template host and port, got %s:%d, want %s:%d", template.Config.Host, template.Config.Port, "localhost", 5433)
+	}
+
+	test, err := c.GetTestDatabase(ctx, "hash")
+	if err != nil {
+		t.Fatalf("failed to get test database: %v", err)
+	}
+
+	if test.Config.Host != "localhost" || test.Config.Port != 5433 {
+		t.Errorf("invalid test database host and port, got %s:%d, want %s:%d", test.Config.Host, test.Config.Port, "localhost", 5433)
+	}
+
+	if test.Config.Database != "integresql_test_hash_001" {
+		t.Errorf("invalid test database, got %q, want %q", test.Config.Database, "integresql_test_hash_001")
+	}
+}
+
+func TestClientConnectionRewrite(t *testing.T) {
+	t.Parallel()
+
+	config := ClientConfig{
+		OverrideHost: "localhost",
+		ConnectionRewrite: func(config models.DatabaseConfig) models.DatabaseConfig {
+			config.SSLMode = "require"
+			return config
+		},
+	}
+
+	c := newStubClientWithConfig(t, config, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":1,"database":{"templateHash":"hash","config":{"host":"postgres","port":5432,"username":"user","password":"pass","database":"integresql_test_hash_001"}}}`))
+	})
+
+	ctx := context.Background()
+
+	test, err := c.GetTestDatabase(ctx, "hash")
+	if err != nil {
+		t.Fatalf("failed to get test database: %v", err)
+	}
+
+	if test.Config.Host != "localhost" || test.Config.SSLMode != "require" {
+		t.Errorf("invalid test database host and sslmode, got %q and %q, want %q and %q", test.Config.Host, test.Config.SSLMode, "localhost", "require")
+	}
+
+	// the rewrite carried by the context replaces the client-level one entirely
+	ctx = WithConnectionRewrite(ctx, func(config models.DatabaseConfig) models.DatabaseConfig {
+		config.Host = "postgres.ci.svc"
+		return config
+	})
+
+	test, err = c.GetTestDatabase(ctx, "hash")
+	if err != nil {
+		t.Fatalf("failed to get test database: %v", err)
+	}
+
+	if test.Config.Host != "postgres.ci.svc" || len(test.Config.SSLMode) > 0 {
+		t.Errorf("invalid test database host and sslmode, got %q and %q, want %q and none", test.Config.Host, test.Config.SSLMode, "postgres.ci.svc")
+	}
+}
+
+// stubDriver is a database/sql driver recording all statements executed per data source name, allowing to test
+// template setup without a database server.
+type stubDriver struct {
+	mu    sync.Mutex
+	execs map[string][]string
+	conns map[string][]int
+	pings map[string]int
+	next  int
+}
+
+var testStubDriver = &stubDriver{execs: make(map[string][]string), conns: make(map[string][]int), pings: make(map[string]int)}
+
+func init() {
+	sql.Register("integresql_stub", testStubDriver)
+}
+
+func (d *stubDriver) Open(name string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.next++
+
+	return &stubConn{driver: d, name: name, id: d.next}, nil
+}
+
+func (d *stubDriver) statements(name string) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return append([]string(nil), d.execs[name]...)
+}
+
+// connections returns the IDs of the connections the statements per data source name were executed on.
+func (d *stubDriver) connections(name string) []int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return append([]int(nil), d.conns[name]...)
+}
+
+type stubConn struct {
+	driver *stubDriver
+	name   string
+	id     int
+}
+
+func (c *stubConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+
+func (c *stubConn) Close() error {
+	return nil
+}
+
+func (c *stubConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+func (c *stubConn) Ping(ctx context.Context) error {
+	if strings.Contains(c.name, "dbname=unreachable") {
+		return errors.New("connection refused")
+	}
+
+	// databases named flaky refuse the first ping, like Postgres not accepting connections yet
+	if strings.Contains(c.name, "flaky") {
+		c.driver.mu.Lock()
+		defer c.driver.mu.Unlock()
+
+		c.driver.pings[c.name]++
+		if c.driver.pings[c.name] == 1 {
+			return errors.New("connection refused")
+		}
+	}
+
+	return nil
+}
+
+func (c *stubConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.driver.mu.Lock()
+	defer c.driver.mu.Unlock()
+
+	c.driver.execs[c.name] = append(c.driver.execs[c.name], query)
+	c.driver.conns[c.name] = append(c.driver.conns[c.name], c.id)
+
+	return driver.RowsAffected(0), nil
+}
+
+func TestClientSetupTemplateFromFS(t *testing.T) {
+	t.Parallel()
+
+	var finalized int32
+	c := newStubClientWithConfig(t, ClientConfig{DriverName: "integresql_stub"}, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/templates":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"database":{"templateHash":"hashfs","config":{"host":"localhost","port":5432,"username":"user","password":"pass","database":"integresql_template_hashfs"}}}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/api/v1/templates/hashfs":
+			atomic.AddInt32(&finalized, 1)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	fsys := fstest.MapFS{
+		"migrations/2_insert.sql":     {Data: []byte("INSERT INTO pilots VALUES (1);")},
+		"migrations/1_create.sql":     {Data: []byte("CREATE TABLE pilots (id int);")},
+		"migrations/README.md":        {Data: []byte("# migrations")},
+		"migrations/nested/3_foo.sql": {Data: []byte("SELECT 1;")},
+	}
+
+	if err := c.SetupTemplateFromFS(context.Background(), "hashfs", fsys, "migrations"); err != nil {
+		t.Fatalf("failed to setup template from fs: %v", err)
+	}
+
+	if n := atomic.LoadInt32(&finalized); n != 1 {
+		t.Errorf("invalid number of finalize requests, got %d, want %d", n, 1)
+	}
+
+	got := testStubDriver.statements("host=localhost port=5432 user=user password=pass dbname=integresql_template_hashfs sslmode=disable")
+	want := []string{"CREATE TABLE pilots (id int);", "INSERT INTO pilots VALUES (1);"}
+
+	if len(got) != len(want) {
+		t.Fatalf("invalid number of executed statements, got %d, want %d", len(got), len(want))
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("invalid statement %d, got %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestClientOpenTestDatabase(t *testing.T) {
+	t.Parallel()
+
+	var id int32
+	c := newStubClientWithConfig(t, ClientConfig{DriverName: "integresql_stub"}, func(w http.ResponseWriter, r *http.Request) {
+		// alternate between two test databases, as if they had been returned to the pool in between
+		n := atomic.AddInt32(&id, 1)%2 + 1
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":%d,"database":{"templateHash":"hash","config":{"host":"localhost","port":5432,"username":"user","password":"pass","database":"integresql_test_hash_%03d"}}}`, n, n)
+	})
+
+	ctx := context.Background()
+
+	dbs := make(map[models.TestDatabaseID]*sql.DB)
+	for i := 0; i < 4; i++ {
+		test, db, err := c.OpenTestDatabase(ctx, "hash")
+		if err != nil {
+			t.Fatalf("failed to open test database: %v", err)
+		}
+
+		if cached, ok := dbs[test.ID]; ok && cached != db {
+			t.Errorf("invalid connection pool for test database %d, got a new pool, want the cached one", test.ID)
+		}
+		dbs[test.ID] = db
+	}
+
+	if len(dbs) != 2 {
+		t.Fatalf("invalid number of connection pools, got %d, want %d", len(dbs), 2)
+	}
+
+	if err := c.CloseContext(ctx); err != nil {
+		t.Fatalf("failed to close client: %v", err)
+	}
+
+	for id, db := range dbs {
+		if err := db.PingContext(ctx); err == nil {
+			t.Errorf("invalid error pinging test database %d after closing the client, got nil, want error", id)
+		}
+	}
+}
+
+func TestClientNegotiateAPIVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		status  int
+		want    string
+		wantErr error
+	}{
+		{
+			name:   "Ready",
+			status: http.StatusOK,
+			want:   APIVersionV1,
+		},
+		{
+			name:   "NotReady",
+			status: http.StatusServiceUnavailable,
+			want:   APIVersionV1,
+		},
+		{
+			name:    "NotSupported",
+			status:  http.StatusNotFound,
+			wantErr: ErrAPIVersionNotSupported,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v1/admin/healthz" {
+					t.Errorf("invalid path, got %q, want %q", r.URL.Path, "/api/v1/admin/healthz")
+				}
+				w.WriteHeader(tt.status)
+			})
+
+			got, err := c.NegotiateAPIVersion(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("invalid error, got %v, want %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("invalid API version, got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientSetupTemplateStatus(t *testing.T) {
+	t.Parallel()
+
+	var initialized int32
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			if atomic.AddInt32(&initialized, 1) > 1 {
+				w.WriteHeader(http.StatusLocked)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"database":{"templateHash":"hash","config":{"host":"localhost","port":5432,"username":"user","password":"pass","database":"integresql_template_hash"}}}`))
+		case http.MethodPut:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	var calls int
+	init := func(conn string) error {
+		calls++
+		return nil
+	}
+
+	for i, want := range []bool{true, false} {
+		created, err := c.SetupTemplateStatus(context.Background(), "hash", init)
+		if err != nil {
+			t.Fatalf("failed to setup template: %v", err)
+		}
+
+		if created != want {
+			t.Errorf("invalid created of setup %d, got %v, want %v", i, created, want)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("invalid number of init calls, got %d, want %d", calls, 1)
+	}
+}
+
+func TestClientSetupTemplateErrors(t *testing.T) {
+	t.Parallel()
+
+	errInit := errors.New("migrations failed")
+
+	tests := []struct {
+		name           string
+		initErr        error
+		finalizeStatus int
+		want           string
+	}{
+		{
+			name:           "InitFailed",
+			initErr:        errInit,
+			finalizeStatus: http.StatusNoContent,
+			want:           `init of template "hash" failed: migrations failed`,
+		},
+		{
+			name:           "FinalizeFailed",
+			finalizeStatus: http.StatusInternalServerError,
+			want:           `template "hash" initialized but finalize failed: finalize template "hash": received unexpected HTTP status 500 (500 Internal Server Error)`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case http.MethodPost:
+					w.Header().Set("Content-Type", "application/json")
+					_, _ = w.Write([]byte(`{"database":{"templateHash":"hash","config":{"host":"localhost","port":5432,"username":"user","password":"pass","database":"integresql_template_hash"}}}`))
+				case http.MethodPut:
+					w.WriteHeader(tt.finalizeStatus)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			})
+
+			err := c.SetupTemplate(context.Background(), "hash", func(conn string) error {
+				return tt.initErr
+			})
+			if err == nil {
+				t.Fatal("invalid error, got nil, want error")
+			}
+
+			if err.Error() != tt.want {
+				t.Errorf("invalid error message, got %q, want %q", err.Error(), tt.want)
+			}
+
+			if tt.initErr != nil && !errors.Is(err, tt.initErr) {
+				t.Errorf("invalid error, got %v, want %v", err, tt.initErr)
+			}
+		})
+	}
+}
+
+func TestClientSetupTemplateDiscardOnInitError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		discard     bool
+		wantDiscard int32
+	}{
+		{
+			name:        "Disabled",
+			discard:     false,
+			wantDiscard: 0,
+		},
+		{
+			name:        "Enabled",
+			discard:     true,
+			wantDiscard: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var discarded int32
+			c := newStubClientWithConfig(t, ClientConfig{DiscardTemplateOnInitError: tt.discard}, func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case http.MethodPost:
+					w.Header().Set("Content-Type", "application/json")
+					_, _ = w.Write([]byte(`{"database":{"templateHash":"hash","config":{"host":"localhost","port":5432,"username":"user","password":"pass","database":"integresql_template_hash"}}}`))
+				case http.MethodDelete:
+					atomic.AddInt32(&discarded, 1)
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					t.Errorf("invalid method, got %s, want %s or %s", r.Method, http.MethodPost, http.MethodDelete)
+				}
+			})
+
+			errInit := errors.New("migrations failed")
+
+			err := c.SetupTemplate(context.Background(), "hash", func(conn string) error {
+				return errInit
+			})
+			if !errors.Is(err, errInit) {
+				t.Errorf("invalid error, got %v, want %v", err, errInit)
+			}
+
+			if n := atomic.LoadInt32(&discarded); n != tt.wantDiscard {
+				t.Errorf("invalid number of discard requests, got %d, want %d", n, tt.wantDiscard)
+			}
+		})
+	}
+}
+
+func TestClientSetupTemplateTimeout(t *testing.T) {
+	t.Parallel()
+
+	var (
+		discarded int32
+		finalized int32
+	)
+
+	c := newStubClientWithConfig(t, ClientConfig{DiscardTemplateOnInitError: true}, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"database":{"templateHash":"hash","config":{"host":"localhost","port":5432,"username":"user","password":"pass","database":"integresql_template_hash"}}}`))
+		case http.MethodPut:
+			atomic.AddInt32(&finalized, 1)
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodDelete:
+			atomic.AddInt32(&discarded, 1)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	err := c.SetupTemplateTimeout(context.Background(), "hash", 10*time.Millisecond, func(ctx context.Context, conn string) error {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("invalid context passed to init, got no deadline, want one")
+		}
+
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("invalid error, got %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	if n := atomic.LoadInt32(&finalized); n != 0 {
+		t.Errorf("invalid number of finalize requests, got %d, want %d", n, 0)
+	}
+
+	// the template is discarded using the caller's context, which is not limited by the timeout
+	if n := atomic.LoadInt32(&discarded); n != 1 {
+		t.Errorf("invalid number of discard requests, got %d, want %d", n, 1)
+	}
+
+	if err := c.SetupTemplateTimeout(context.Background(), "hash", time.Second, func(ctx context.Context, conn string) error {
+		return nil
+	}); err != nil {
+		t.Fatalf("failed to setup template: %v", err)
+	}
+
+	if n := atomic.LoadInt32(&finalized); n != 1 {
+		t.Errorf("invalid number of finalize requests, got %d, want %d", n, 1)
+	}
+}
+
+func TestClientDo(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/admin/custom":
+			if r.Method != http.MethodPost {
+				t.Errorf("invalid method, got %s, want %s", r.Method, http.MethodPost)
+			}
+			if ct := r.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+				t.Errorf("invalid content type, got %q, want application/json", ct)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"value":"created"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"no such endpoint"}`))
+		}
+	})
+
+	ctx := context.Background()
+
+	var out struct {
+		Value string `json:"value"`
+	}
+
+	status, err := c.Do(ctx, http.MethodPost, "/admin/custom", map[string]string{"key": "value"}, &out)
+	if err != nil {
+		t.Fatalf("failed to call custom endpoint: %v", err)
+	}
+
+	if status != http.StatusCreated {
+		t.Errorf("invalid status code, got %d, want %d", status, http.StatusCreated)
+	}
+
+	if out.Value != "created" {
+		t.Errorf("invalid response, got %q, want %q", out.Value, "created")
+	}
+
+	status, err = c.Do(ctx, http.MethodGet, "/admin/unknown", nil, nil)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("invalid error, got %v, want *APIError", err)
+	}
+
+	if status != http.StatusNotFound {
+		t.Errorf("invalid status code, got %d, want %d", status, http.StatusNotFound)
+	}
+
+	if apiErr.Message != "no such endpoint" {
+		t.Errorf("invalid message, got %q, want %q", apiErr.Message, "no such endpoint")
+	}
+}
+
+func TestClientDoQuery(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/admin/foo" {
+			t.Errorf("invalid path, got %q, want %q", r.URL.Path, "/api/v1/admin/foo")
+		}
+		if r.URL.RawQuery != "verbose=1" {
+			t.Errorf("invalid query, got %q, want %q", r.URL.RawQuery, "verbose=1")
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if _, err := c.Do(context.Background(), http.MethodGet, "/admin/foo?verbose=1", nil, nil); err != nil {
+		t.Fatalf("failed to call custom endpoint: %v", err)
+	}
+}
+
+func TestClientMaxResponseBytes(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClientWithConfig(t, ClientConfig{MaxResponseBytes: 1024}, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":1,"database":{"templateHash":"` + strings.Repeat("a", 4096) + `"}}`))
+	})
+
+	if _, err := c.GetTestDatabase(context.Background(), "hash"); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("invalid error, got %v, want %v", err, ErrResponseTooLarge)
+	}
+}
+
+func TestClientTemplateStats(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("invalid method, got %s, want %s", r.Method, http.MethodGet)
+		}
+		switch r.URL.Path {
+		case "/api/v1/templates/hash/stats":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"ready":3,"dirty":1,"inUse":4}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	ctx := context.Background()
+
+	stats, err := c.TemplateStats(ctx, "hash")
+	if err != nil {
+		t.Fatalf("failed to get template stats: %v", err)
+	}
+
+	if want := (models.TemplatePoolStats{Ready: 3, Dirty: 1, InUse: 4}); stats != want {
+		t.Errorf("invalid template stats, got %+v, want %+v", stats, want)
+	}
+
+	if _, err := c.TemplateStats(ctx, "unknown"); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("invalid error, got %v, want %v", err, ErrTemplateNotFound)
+	}
+}
+
+func TestClientTemplateExists(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("invalid method, got %s, want %s", r.Method, http.MethodGet)
+		}
+		switch r.URL.Path {
+		case "/api/v1/templates/hash":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"database":{"templateHash":"hash"}}`))
+		case "/api/v1/templates/failing":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	ctx := context.Background()
+
+	tests := []struct {
+		hash    string
+		want    bool
+		wantErr bool
+	}{
+		{hash: "hash", want: true},
+		{hash: "unknown", want: false},
+		{hash: "failing", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := c.TemplateExists(ctx, tt.hash)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("invalid error for hash %q, got %v, want error %v", tt.hash, err, tt.wantErr)
+		}
+
+		if got != tt.want {
+			t.Errorf("invalid result for hash %q, got %v, want %v", tt.hash, got, tt.want)
+		}
+	}
+}
+
+func TestClientGetTestDatabaseLabeled(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		received []string
+	)
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = append(received, r.Header.Get("X-Test-Label"))
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":1,"database":{"templateHash":"hash"}}`))
+	})
+
+	ctx := context.Background()
+
+	test, err := c.GetTestDatabaseLabeled(ctx, "hash", "TestUserCreate/valid_email")
+	if err != nil {
+		t.Fatalf("failed to get test database: %v", err)
+	}
+
+	if test.ID != 1 {
+		t.Errorf("invalid test database ID, got %d, want %d", test.ID, 1)
+	}
+
+	if _, err := c.GetTestDatabase(ctx, "hash"); err != nil {
+		t.Fatalf("failed to get test database: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for i, want := range []string{"TestUserCreate/valid_email", ""} {
+		if received[i] != want {
+			t.Errorf("invalid label of request %d, got %q, want %q", i, received[i], want)
+		}
+	}
+}
+
+func TestClientGetTestDatabaseResponse(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-ID", "request-1")
+		_, _ = w.Write([]byte(`{"id":1,"database":{"templateHash":"hash"}}`))
+	})
+
+	test, resp, err := c.GetTestDatabaseResponse(context.Background(), "hash")
+	if err != nil {
+		t.Fatalf("failed to get test database: %v", err)
+	}
+
+	if test.ID != 1 {
+		t.Errorf("invalid test database ID, got %d, want %d", test.ID, 1)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("invalid status code, got %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	if got := resp.Header.Get("X-Request-ID"); got != "request-1" {
+		t.Errorf("invalid header, got %q, want %q", got, "request-1")
+	}
+
+	if resp.Body != http.NoBody {
+		t.Error("invalid response body, got an unconsumed body, want http.NoBody")
+	}
+}
+
+func TestClientInitializeTemplateResponse(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-ID", "request-2")
+		w.WriteHeader(http.StatusLocked)
+	})
+
+	_, resp, err := c.InitializeTemplateResponse(context.Background(), "hash")
+	if !errors.Is(err, ErrTemplateAlreadyInitialized) {
+		t.Errorf("invalid error, got %v, want %v", err, ErrTemplateAlreadyInitialized)
+	}
+
+	if resp == nil {
+		t.Fatal("invalid response, got nil, want response")
+	}
+
+	if got := resp.Header.Get("X-Request-ID"); got != "request-2" {
+		t.Errorf("invalid header, got %q, want %q", got, "request-2")
+	}
+}
+
+func TestClientVerifyTemplateHash(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("invalid request, got %s %s, want no request to the manager", r.Method, r.URL.Path)
+	})
+
+	ctx := context.Background()
+
+	if err := c.VerifyTemplateHash(ctx, "hash", "schema1"); err != nil {
+		t.Errorf("failed to record fingerprint: %v", err)
+	}
+
+	if err := c.VerifyTemplateHash(ctx, "hash", "schema1"); err != nil {
+		t.Errorf("failed to verify matching fingerprint: %v", err)
+	}
+
+	if err := c.VerifyTemplateHash(ctx, "other", "schema2"); err != nil {
+		t.Errorf("failed to record fingerprint of other template: %v", err)
+	}
+
+	if err := c.VerifyTemplateHash(ctx, "hash", "schema2"); !errors.Is(err, ErrTemplateHashCollision) {
+		t.Errorf("invalid error, got %v, want %v", err, ErrTemplateHashCollision)
+	}
+}
+
+func TestClientSetupTemplateWithDBClientOpts(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClientWithConfig(t, ClientConfig{DriverName: "integresql_stub"}, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"database":{"templateHash":"hashopts","config":{"host":"localhost","port":5432,"username":"user","password":"pass","database":"integresql_template_hashopts"}}}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	opts := DBOptions{MaxOpenConns: 1}
+
+	if err := c.SetupTemplateWithDBClientOpts(context.Background(), "hashopts", opts, func(db *sql.DB) error {
+		if n := db.Stats().MaxOpenConnections; n != 1 {
+			t.Errorf("invalid max open connections, got %d, want %d", n, 1)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("failed to setup template: %v", err)
+	}
+}
+
+func TestClientSetupTemplateWithDBClientPingRetry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		pingRetries int
+		wantErr     bool
+	}{
+		{name: "Retried", pingRetries: 2},
+		{name: "NotRetried", pingRetries: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			hash := "flaky" + strings.ToLower(tt.name)
+			config := ClientConfig{DriverName: "integresql_stub", PingRetries: tt.pingRetries, PingBackoff: time.Millisecond}
+
+			var finalized int32
+			c := newStubClientWithConfig(t, config, func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case http.MethodPost:
+					w.Header().Set("Content-Type", "application/json")
+					fmt.Fprintf(w, `{"database":{"templateHash":%q,"config":{"host":"localhost","port":5432,"username":"user","password":"pass","database":"integresql_template_%s"}}}`, hash, hash)
+				case http.MethodPut:
+					atomic.AddInt32(&finalized, 1)
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					w.WriteHeader(http.StatusNoContent)
+				}
+			})
+
+			err := c.SetupTemplateWithDBClient(context.Background(), hash, func(db *sql.DB) error {
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("invalid error, got %v, want error %v", err, tt.wantErr)
+			}
+
+			want := int32(1)
+			if tt.wantErr {
+				want = 0
+			}
+
+			if n := atomic.LoadInt32(&finalized); n != want {
+				t.Errorf("invalid number of finalizations, got %d, want %d", n, want)
+			}
+		})
+	}
+}
+
+func TestClientSetupTemplateWithConnection(t *testing.T) {
+	t.Parallel()
+
+	errConnect := errors.New("connection refused")
+
+	tests := []struct {
+		name          string
+		connectErr    error
+		pingFailures  int
+		wantErr       error
+		wantDiscarded bool
+		wantFinalized bool
+	}{
+		{name: "Success", wantFinalized: true},
+		{name: "PingRetried", pingFailures: 1, wantFinalized: true},
+		{name: "ConnectFailed", connectErr: errConnect, wantErr: errConnect, wantDiscarded: true},
+		{name: "PingFailed", pingFailures: 5, wantErr: errConnect, wantDiscarded: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var discarded, finalized int32
+			config := ClientConfig{DiscardTemplateOnInitError: true, PingRetries: 2, PingBackoff: time.Millisecond}
+			c := newStubClientWithConfig(t, config, func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case http.MethodPost:
+					w.Header().Set("Content-Type", "application/json")
+					_, _ = w.Write([]byte(`{"database":{"templateHash":"hashconn","config":{"host":"localhost","port":5432,"username":"user","password":"pass","database":"integresql_template_hashconn"}}}`))
+				case http.MethodPut:
+					atomic.AddInt32(&finalized, 1)
+					w.WriteHeader(http.StatusNoContent)
+				case http.MethodDelete:
+					atomic.AddInt32(&discarded, 1)
+					w.WriteHeader(http.StatusNoContent)
+				}
+			})
+
+			var pings, closed int
+			connect := func(ctx context.Context, config models.DatabaseConfig) (func(ctx context.Context) error, func(), error) {
+				if tt.connectErr != nil {
+					return nil, nil, tt.connectErr
+				}
+
+				ping := func(ctx context.Context) error {
+					pings++
+					if pings <= tt.pingFailures {
+						return errConnect
+					}
+					return nil
+				}
+
+				return ping, func() { closed++ }, nil
+			}
+
+			err := c.SetupTemplateWithConnection(context.Background(), "hashconn", connect, func(ctx context.Context) error {
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("invalid error, got %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.connectErr == nil && closed != 1 {
+				t.Errorf("invalid number of closed connections, got %d, want %d", closed, 1)
+			}
+
+			if got := atomic.LoadInt32(&discarded) == 1; got != tt.wantDiscarded {
+				t.Errorf("invalid discarded state, got %v, want %v", got, tt.wantDiscarded)
+			}
+
+			if got := atomic.LoadInt32(&finalized) == 1; got != tt.wantFinalized {
+				t.Errorf("invalid finalized state, got %v, want %v", got, tt.wantFinalized)
+			}
+		})
+	}
+}
+
+// flakyTransport fails the first request it receives with err, forwarding all further requests to the default transport.
+type flakyTransport struct {
+	err   error
+	calls int32
+}
+
+func (t *flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if atomic.AddInt32(&t.calls, 1) == 1 {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, t.err
+	}
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClientRetryConnectionReset(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		err           error
+		nonIdempotent bool
+		call          func(c *Client) error
+		wantCalls     int32
+		wantErr       bool
+	}{
+		{
+			name: "IdempotentEOF",
+			err:  io.EOF,
+			call: func(c *Client) error {
+				_, err := c.GetTemplate(context.Background(), "hash")
+				return err
+			},
+			wantCalls: 2,
+		},
+		{
+			// retrying could check out a second test database if the manager handled the first request
+			name: "GetTestDatabase",
+			err:  io.EOF,
+			call: func(c *Client) error {
+				_, err := c.GetTestDatabase(context.Background(), "hash")
+				return err
+			},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:          "GetTestDatabaseNonIdempotentEnabled",
+			err:           io.EOF,
+			nonIdempotent: true,
+			call: func(c *Client) error {
+				_, err := c.GetTestDatabase(context.Background(), "hash")
+				return err
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "IdempotentConnectionReset",
+			err:  syscall.ECONNRESET,
+			call: func(c *Client) error {
+				return c.FinalizeTemplate(context.Background(), "hash")
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "NonIdempotent",
+			err:  io.ErrUnexpectedEOF,
+			call: func(c *Client) error {
+				_, err := c.InitializeTemplate(context.Background(), "hash")
+				return err
+			},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:          "NonIdempotentEnabled",
+			err:           io.ErrUnexpectedEOF,
+			nonIdempotent: true,
+			call: func(c *Client) error {
+				_, err := c.InitializeTemplate(context.Background(), "hash")
+				return err
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "OtherError",
+			err:  errors.New("connection refused"),
+			call: func(c *Client) error {
+				_, err := c.GetTestDatabase(context.Background(), "hash")
+				return err
+			},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newStubClientWithConfig(t, ClientConfig{RetryNonIdempotentOnReset: tt.nonIdempotent}, func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case http.MethodPut:
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					w.Header().Set("Content-Type", "application/json")
+					_, _ = w.Write([]byte(`{"id":1,"database":{"templateHash":"hash"}}`))
+				}
+			})
+
+			transport := &flakyTransport{err: tt.err}
+			c.SetClient(&http.Client{Transport: transport})
+
+			if err := tt.call(c); (err != nil) != tt.wantErr {
+				t.Errorf("invalid error, got %v, want error %v", err, tt.wantErr)
+			}
+
+			if n := atomic.LoadInt32(&transport.calls); n != tt.wantCalls {
+				t.Errorf("invalid number of attempts, got %d, want %d", n, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestClientResetTestDatabases(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("invalid method, got %s, want %s", r.Method, http.MethodDelete)
+		}
+		switch r.URL.Path {
+		case "/api/v1/templates/hash/tests":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	ctx := context.Background()
+
+	if err := c.ResetTestDatabases(ctx, "hash"); err != nil {
+		t.Errorf("failed to reset test databases: %v", err)
+	}
+
+	if err := c.ResetTestDatabases(ctx, "unknown"); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("invalid error, got %v, want %v", err, ErrTemplateNotFound)
+	}
+}
+
+func TestClientFinalizeTemplateAlreadyFinalized(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "Conflict", status: http.StatusConflict},
+		{name: "Locked", status: http.StatusLocked},
+		{name: "InternalServerError", status: http.StatusInternalServerError, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls int32
+			c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPut {
+					t.Errorf("invalid method, got %s, want %s", r.Method, http.MethodPut)
+				}
+
+				// the first worker finalizes the template, the second one finds it already finalized
+				if atomic.AddInt32(&calls, 1) == 1 {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				w.WriteHeader(tt.status)
+			})
+
+			ctx := context.Background()
+
+			if err := c.FinalizeTemplate(ctx, "hash"); err != nil {
+				t.Fatalf("failed to finalize template: %v", err)
+			}
+
+			if err := c.FinalizeTemplate(ctx, "hash"); (err != nil) != tt.wantErr {
+				t.Errorf("invalid error finalizing the template again, got %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClientResetTemplate(t *testing.T) {
+	t.Parallel()
+
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("invalid method, got %s, want %s", r.Method, http.MethodPost)
+		}
+		switch r.URL.Path {
+		case "/api/v1/templates/hash/reset":
+			w.WriteHeader(http.StatusNoContent)
+		case "/api/v1/templates/discarded/reset":
+			w.WriteHeader(http.StatusGone)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	ctx := context.Background()
+
+	tests := []struct {
+		hash    string
+		wantErr error
+	}{
+		{hash: "hash", wantErr: nil},
+		{hash: "discarded", wantErr: ErrDatabaseDiscarded},
+		{hash: "unknown", wantErr: ErrTemplateNotFound},
+	}
+
+	for _, tt := range tests {
+		if err := c.ResetTemplate(ctx, tt.hash); !errors.Is(err, tt.wantErr) {
+			t.Errorf("invalid error for hash %q, got %v, want %v", tt.hash, err, tt.wantErr)
+		}
+	}
+}
+
+func TestClientStrictJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		strict  bool
+		body    string
+		wantErr bool
+	}{
+		{
+			name:   "Lenient",
+			strict: false,
+			body:   `{"id":1,"unknown":true,"database":{"templateHash":"hash"}}`,
+		},
+		{
+			name:    "Strict",
+			strict:  true,
+			body:    `{"id":1,"unknown":true,"database":{"templateHash":"hash"}}`,
+			wantErr: true,
+		},
+		{
+			name:   "LenientNestedInConfig",
+			strict: false,
+			body:   `{"id":1,"database":{"templateHash":"hash","config":{"host":"localhost","port":"5432","unknown":true}}}`,
+		},
+		{
+			name:    "StrictNestedInConfig",
+			strict:  true,
+			body:    `{"id":1,"database":{"templateHash":"hash","config":{"host":"localhost","port":"5432","unknown":true}}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newStubClientWithConfig(t, ClientConfig{StrictJSON: tt.strict}, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.body))
+			})
+
+			if _, err := c.GetTestDatabase(context.Background(), "hash"); (err != nil) != tt.wantErr {
+				t.Errorf("invalid error, got %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClientPing(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		database string
+		wantErr  bool
+	}{
+		{
+			name:     "Reachable",
+			database: "integresql_test_hash_001",
+		},
+		{
+			name:     "Unreachable",
+			database: "unreachable",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var returned int32
+			c := newStubClientWithConfig(t, ClientConfig{DriverName: "integresql_stub"}, func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case http.MethodGet:
+					w.Header().Set("Content-Type", "application/json")
+					fmt.Fprintf(w, `{"id":1,"database":{"templateHash":"hash","config":{"host":"localhost","port":5432,"username":"user","password":"pass","database":%q}}}`, tt.database)
+				case http.MethodDelete:
+					atomic.AddInt32(&returned, 1)
+					w.WriteHeader(http.StatusNoContent)
+				}
+			})
+
+			if err := c.Ping(context.Background(), "hash"); (err != nil) != tt.wantErr {
+				t.Errorf("invalid error, got %v, want error %v", err, tt.wantErr)
+			}
+
+			if n := atomic.LoadInt32(&returned); n != 1 {
+				t.Errorf("invalid number of returned test databases, got %d, want %d", n, 1)
+			}
+		})
+	}
+}
+
+func TestClientOperationTimeouts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		deadline time.Duration
+		wantErr  error
+	}{
+		{
+			name:    "OperationTimeout",
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name:     "CallerDeadlineWins",
+			deadline: 5 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			config := ClientConfig{
+				OperationTimeouts: map[string]time.Duration{
+					"GetTestDatabase": 20 * time.Millisecond,
+				},
+			}
+
+			c := newStubClientWithConfig(t, config, func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+					return
+				case <-time.After(100 * time.Millisecond):
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"id":1,"database":{"templateHash":"hash"}}`))
+			})
+
+			ctx := context.Background()
+			if tt.deadline > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.deadline)
+				defer cancel()
+			}
+
+			if _, err := c.GetTestDatabase(ctx, "hash"); !errors.Is(err, tt.wantErr) {
+				t.Errorf("invalid error, got %v, want %v", err, tt.wantErr)
+			}
+
+			// other operations are not limited
+			if err := c.FinalizeTemplate(context.Background(), "hash"); err == nil || errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("invalid error, got %v, want unexpected HTTP status", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package integresql
+
+import (
+	"context"
+
+	"github.com/allaboutapps/integresql-client-go/pkg/models"
+)
+
+// Operation describes the client method a request to the manager is sent for.
+type Operation struct {
+	Name   string                // name of the client method, e.g. "InitializeTemplate"
+	Hash   string                // template hash, if any
+	TestID models.TestDatabaseID // test database ID, if any
+
+	Attempt int // 1-based attempt if the client retries the whole operation on its own (e.g. GetTestDatabase on 429 Too Many Requests), 0 otherwise
+}
+
+// Tracer starts a span for each operation sent to the manager, covering all retried attempts.
+// The returned func ends the span, receiving the final status code (0 if no response was received) and error.
+type Tracer interface {
+	StartSpan(ctx context.Context, op Operation) (context.Context, func(statusCode int, err error))
+}
+
+type operationContextKey struct{}
+
+// withOperation returns a copy of ctx carrying op, generating a request ID shared by all requests of the
+// operation unless one has been set via WithRequestID.
+func withOperation(ctx context.Context, op Operation) context.Context {
+	if _, ok := RequestIDFromContext(ctx); !ok {
+		ctx = WithRequestID(ctx, NewRequestID())
+	}
+
+	return context.WithValue(ctx, operationContextKey{}, op)
+}
+
+// OperationFromContext returns the operation a request to the manager is sent for, allowing
+// e.g. a custom http.RoundTripper to inspect it.
+func OperationFromContext(ctx context.Context) (Operation, bool) {
+	op, ok := ctx.Value(operationContextKey{}).(Operation)
+	return op, ok
+}
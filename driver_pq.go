@@ -0,0 +1,8 @@
+//go:build !integresql_nopq
+// +build !integresql_nopq
+
+package integresql
+
+// lib/pq is registered as the default "postgres" driver, build with the integresql_nopq tag to omit it
+// e.g. if you're solely relying on pgx's stdlib driver
+import _ "github.com/lib/pq"
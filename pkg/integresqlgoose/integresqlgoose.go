@@ -0,0 +1,37 @@
+package integresqlgoose
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/pressly/goose/v3"
+
+	"github.com/allaboutapps/integresql-client-go"
+)
+
+// MigrateTemplateGoose initializes the template identified by hash, applies all up migrations found in dir to the
+// template database using goose and finalizes the template afterwards. Already initialized templates are skipped.
+func MigrateTemplateGoose(ctx context.Context, c *integresql.Client, hash string, dir string) error {
+	return MigrateTemplateGooseFS(ctx, c, hash, os.DirFS(dir))
+}
+
+// MigrateTemplateGooseFS works like MigrateTemplateGoose, but reads the migrations from the root of fsys,
+// e.g. an embed.FS (use fs.Sub to select a subdirectory).
+func MigrateTemplateGooseFS(ctx context.Context, c *integresql.Client, hash string, fsys fs.FS) error {
+	return c.SetupTemplateWithDBClient(ctx, hash, func(db *sql.DB) error {
+		// the provider is not closed as this would close db, which is owned by SetupTemplateWithDBClient
+		provider, err := goose.NewProvider(goose.DialectPostgres, db, fsys)
+		if err != nil {
+			return err
+		}
+
+		if _, err := provider.Up(ctx); err != nil {
+			return fmt.Errorf("failed to apply migrations: %w", err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE TABLE pilots (id int NOT NULL, "name" text NOT NULL);
+
+-- +goose Down
+DROP TABLE pilots;
@@ -0,0 +1,5 @@
+-- +goose Up
+INSERT INTO pilots (id, "name") VALUES (1, 'Mario'), (2, 'Nick');
+
+-- +goose Down
+DELETE FROM pilots;
@@ -0,0 +1,42 @@
+package integresqlgorm
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/allaboutapps/integresql-client-go"
+)
+
+// OpenTest retrieves a test database for the template identified by hash and opens it using GORM's Postgres driver,
+// respecting the client's OverrideHost/OverridePort settings. The returned cleanup func closes the connection and
+// releases the test database via ReleaseTestDatabase, it must be called once the test database is no longer needed.
+// If opening the test database fails, it is returned to the pool unchanged before returning the error.
+func OpenTest(ctx context.Context, c *integresql.Client, hash string, opts ...gorm.Option) (*gorm.DB, func() error, error) {
+	test, err := c.GetTestDatabase(ctx, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	returnTest := func() error {
+		return c.ReturnTestDatabase(ctx, hash, test.ID)
+	}
+
+	db, err := gorm.Open(postgres.Open(test.Config.ConnectionString()), opts...)
+	if err != nil {
+		return nil, nil, errors.Join(err, returnTest())
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, nil, errors.Join(err, returnTest())
+	}
+
+	cleanup := func() error {
+		return errors.Join(sqlDB.Close(), c.ReleaseTestDatabase(ctx, hash, test.ID))
+	}
+
+	return db.WithContext(ctx), cleanup, nil
+}
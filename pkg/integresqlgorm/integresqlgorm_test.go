@@ -0,0 +1,55 @@
+package integresqlgorm
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/allaboutapps/integresql-client-go"
+)
+
+type pilot struct {
+	ID   int
+	Name string
+}
+
+func TestOpenTest(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := integresql.DefaultClientFromEnv()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	hash := "hashinghashgorm1"
+
+	if err := c.SetupTemplateWithDBClient(ctx, hash, func(db *sql.DB) error {
+		if _, err := db.ExecContext(ctx, `CREATE TABLE pilots (id int NOT NULL, "name" text NOT NULL)`); err != nil {
+			return err
+		}
+
+		_, err := db.ExecContext(ctx, `INSERT INTO pilots (id, "name") VALUES (1, 'Mario')`)
+		return err
+	}); err != nil {
+		t.Fatalf("failed to setup template database for hash %q: %v", hash, err)
+	}
+
+	db, cleanup, err := OpenTest(ctx, c, hash)
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	defer func() {
+		if err := cleanup(); err != nil {
+			t.Errorf("failed to cleanup test database: %v", err)
+		}
+	}()
+
+	var pilots []pilot
+	if err := db.Find(&pilots).Error; err != nil {
+		t.Fatalf("failed to find pilots: %v", err)
+	}
+
+	if len(pilots) != 1 || pilots[0].Name != "Mario" {
+		t.Errorf("invalid pilots, got %+v, want a single pilot named %q", pilots, "Mario")
+	}
+}
@@ -0,0 +1,52 @@
+package integresqlmigrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file" // registers the file:// migration source
+
+	"github.com/allaboutapps/integresql-client-go"
+)
+
+// MigrateTemplate initializes the template identified by hash, applies all up migrations found in migrationsDir
+// to the template database using golang-migrate and finalizes the template afterwards. Already initialized
+// templates are skipped. The template database connection is opened using the client's configured DriverName.
+func MigrateTemplate(ctx context.Context, c *integresql.Client, hash string, migrationsDir string) error {
+	dir, err := filepath.Abs(migrationsDir)
+	if err != nil {
+		return err
+	}
+
+	return c.SetupTemplateWithDBClient(ctx, hash, func(db *sql.DB) error {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			return err
+		}
+
+		// the driver only owns the single connection, the database is closed by SetupTemplateWithDBClient
+		driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
+		if err != nil {
+			conn.Close()
+			return err
+		}
+
+		m, err := migrate.NewWithDatabaseInstance("file://"+filepath.ToSlash(dir), "postgres", driver)
+		if err != nil {
+			driver.Close()
+			return err
+		}
+		defer m.Close()
+
+		if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return fmt.Errorf("failed to apply migrations from %q: %w", migrationsDir, err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,45 @@
+package integresqlmigrate
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/allaboutapps/integresql-client-go"
+)
+
+func TestMigrateTemplate(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := integresql.DefaultClientFromEnv()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	hash := "hashinghashmigrate1"
+
+	if err := MigrateTemplate(ctx, c, hash, "testdata/migrations"); err != nil {
+		t.Fatalf("failed to migrate template database for hash %q: %v", hash, err)
+	}
+
+	test, err := c.GetTestDatabase(ctx, hash)
+	if err != nil {
+		t.Fatalf("failed to get test database: %v", err)
+	}
+	defer c.ReturnTestDatabase(ctx, hash, test.ID) //nolint:errcheck
+
+	db, err := sql.Open(c.Config().DriverName, test.Config.ConnectionString())
+	if err != nil {
+		t.Fatalf("failed to open test database connection: %v", err)
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM pilots").Scan(&count); err != nil {
+		t.Fatalf("failed to query test database: %v", err)
+	}
+
+	if count != 2 {
+		t.Errorf("invalid number of pilots, got %d, want %d", count, 2)
+	}
+}
@@ -0,0 +1 @@
+CREATE TABLE pilots (id int NOT NULL, "name" text NOT NULL);
@@ -0,0 +1 @@
+INSERT INTO pilots (id, "name") VALUES (1, 'Mario'), (2, 'Nick');
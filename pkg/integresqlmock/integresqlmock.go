@@ -0,0 +1,316 @@
+package integresqlmock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/allaboutapps/integresql-client-go/pkg/models"
+)
+
+// apiPrefix is the path prefix of all endpoints served by the fake manager.
+const apiPrefix = "/api/v1"
+
+// Server is an in-memory fake of the IntegreSQL manager's API (v1), allowing to test code using the client without
+// a running IntegreSQL. It implements the happy paths of all endpoints used by the client, returning deterministic
+// database configs, and supports injecting errors via FailCall. No databases are actually created.
+type Server struct {
+	srv *httptest.Server
+
+	mu        sync.Mutex
+	calls     int
+	failures  map[int]int // status codes to respond with, keyed by the (1-based) number of the call
+	templates map[string]*template
+}
+
+type template struct {
+	finalized bool
+	tests     []bool // locked state of the test databases of the template, indexed by ID
+}
+
+// NewServer starts a new fake manager, which must be closed once it is no longer needed.
+func NewServer() *Server {
+	s := &Server{
+		failures:  make(map[int]int),
+		templates: make(map[string]*template),
+	}
+
+	s.srv = httptest.NewServer(http.HandlerFunc(s.handle))
+
+	return s
+}
+
+// URL returns the base URL of the fake manager to be used as the client's BaseURL (with API version v1).
+func (s *Server) URL() string {
+	return s.srv.URL + "/api"
+}
+
+// Close shuts down the fake manager.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// FailCall makes the fake manager respond with the given status code (e.g. http.StatusServiceUnavailable to
+// return ErrManagerNotReady) to the n-th call it receives, counting from 1 across all endpoints.
+func (s *Server) FailCall(n int, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures[n] = status
+}
+
+// Calls returns the number of calls the fake manager received so far.
+func (s *Server) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls
+}
+
+// TemplateConfig returns the deterministic database config of the template identified by hash.
+func TemplateConfig(hash string) models.DatabaseConfig {
+	return databaseConfig(fmt.Sprintf("integresql_template_%s", hash))
+}
+
+// TestConfig returns the deterministic database config of the test database with the given ID of the template
+// identified by hash.
+func TestConfig(hash string, id models.TestDatabaseID) models.DatabaseConfig {
+	return databaseConfig(fmt.Sprintf("integresql_test_%s_%03d", hash, id))
+}
+
+func databaseConfig(database string) models.DatabaseConfig {
+	return models.DatabaseConfig{
+		Host:     "localhost",
+		Port:     5432,
+		Username: "integresql",
+		Password: "integresql",
+		Database: database,
+	}
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	if status, ok := s.failures[s.calls]; ok {
+		w.WriteHeader(status)
+		return
+	}
+
+	if !strings.HasPrefix(r.URL.Path, apiPrefix+"/") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, apiPrefix), "/"), "/")
+
+	switch {
+	case r.Method == http.MethodGet && match(parts, "admin", "healthz"):
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodGet && match(parts, "admin", "templates"):
+		s.listTemplates(w)
+	case r.Method == http.MethodDelete && match(parts, "admin", "templates"):
+		s.templates = make(map[string]*template)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && match(parts, "templates"):
+		s.initializeTemplate(w, r)
+	case match(parts, "templates", "*"):
+		s.handleTemplate(w, r, parts[1])
+	case r.Method == http.MethodPost && match(parts, "templates", "*", "reset"):
+		s.resetTemplate(w, parts[1])
+	case r.Method == http.MethodGet && match(parts, "templates", "*", "stats"):
+		s.templateStats(w, parts[1])
+	case match(parts, "templates", "*", "tests"):
+		s.handleTests(w, r, parts[1])
+	case match(parts, "templates", "*", "tests", "*"), match(parts, "templates", "*", "tests", "*", "recreate"):
+		s.handleTest(w, r, parts[1], parts[3], len(parts) == 5)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// match reports whether the path parts equal pattern, with "*" matching any single part.
+func match(parts []string, pattern ...string) bool {
+	if len(parts) != len(pattern) {
+		return false
+	}
+
+	for i := range pattern {
+		if pattern[i] != "*" && pattern[i] != parts[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *Server) listTemplates(w http.ResponseWriter) {
+	hashes := make([]string, 0, len(s.templates))
+	for hash := range s.templates {
+		hashes = append(hashes, hash)
+	}
+
+	sort.Strings(hashes)
+
+	templates := make([]models.TemplateDatabase, 0, len(hashes))
+	for _, hash := range hashes {
+		templates = append(templates, templateDatabase(hash))
+	}
+
+	writeJSON(w, templates)
+}
+
+func (s *Server) initializeTemplate(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Hash string `json:"hash"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || len(payload.Hash) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := s.templates[payload.Hash]; ok {
+		w.WriteHeader(http.StatusLocked)
+		return
+	}
+
+	s.templates[payload.Hash] = &template{}
+
+	writeJSON(w, templateDatabase(payload.Hash))
+}
+
+func (s *Server) handleTemplate(w http.ResponseWriter, r *http.Request, hash string) {
+	t, ok := s.templates[hash]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, templateDatabase(hash))
+	case http.MethodPut:
+		t.finalized = true
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		delete(s.templates, hash)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) resetTemplate(w http.ResponseWriter, hash string) {
+	t, ok := s.templates[hash]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	// test databases are recreated from the template, previously handed out ones are invalidated
+	t.tests = nil
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) templateStats(w http.ResponseWriter, hash string) {
+	t, ok := s.templates[hash]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	// returned test databases are immediately reusable, the fake never reports dirty ones
+	var stats models.TemplatePoolStats
+	for _, locked := range t.tests {
+		if locked {
+			stats.InUse++
+		} else {
+			stats.Ready++
+		}
+	}
+
+	writeJSON(w, stats)
+}
+
+func (s *Server) handleTests(w http.ResponseWriter, r *http.Request, hash string) {
+	t, ok := s.templates[hash]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		// the real manager waits for the template to be finalized, let the client retry instead
+		if !t.finalized {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		id := -1
+		for i, locked := range t.tests {
+			if !locked {
+				id = i
+				break
+			}
+		}
+
+		if id < 0 {
+			id = len(t.tests)
+			t.tests = append(t.tests, false)
+		}
+
+		t.tests[id] = true
+
+		writeJSON(w, models.TestDatabase{
+			Database: models.Database{TemplateHash: hash, Config: TestConfig(hash, models.TestDatabaseID(id))},
+			ID:       models.TestDatabaseID(id),
+		})
+	case http.MethodDelete:
+		for i := range t.tests {
+			t.tests[i] = false
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleTest(w http.ResponseWriter, r *http.Request, hash string, rawID string, recreate bool) {
+	t, ok := s.templates[hash]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	id, err := strconv.Atoi(rawID)
+	if err != nil || id < 0 || id >= len(t.tests) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if (recreate && r.Method != http.MethodPost) || (!recreate && r.Method != http.MethodDelete) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	t.tests[id] = false
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func templateDatabase(hash string) models.TemplateDatabase {
+	return models.TemplateDatabase{
+		Database: models.Database{TemplateHash: hash, Config: TemplateConfig(hash)},
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,155 @@
+package integresqlmock_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/allaboutapps/integresql-client-go"
+	"github.com/allaboutapps/integresql-client-go/pkg/integresqlmock"
+	"github.com/allaboutapps/integresql-client-go/pkg/models"
+)
+
+func newClient(t *testing.T, s *integresqlmock.Server) *integresql.Client {
+	t.Helper()
+
+	c, err := integresql.NewClient(integresql.ClientConfig{BaseURL: s.URL(), APIVersion: "v1"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	return c
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	s := integresqlmock.NewServer()
+	defer s.Close()
+
+	c := newClient(t, s)
+	ctx := context.Background()
+
+	template, err := c.InitializeTemplate(ctx, "hash")
+	if err != nil {
+		t.Fatalf("failed to initialize template: %v", err)
+	}
+
+	if !reflect.DeepEqual(template.Config, integresqlmock.TemplateConfig("hash")) {
+		t.Errorf("invalid template config, got %+v, want %+v", template.Config, integresqlmock.TemplateConfig("hash"))
+	}
+
+	if _, err := c.InitializeTemplate(ctx, "hash"); !errors.Is(err, integresql.ErrTemplateAlreadyInitialized) {
+		t.Errorf("invalid error, got %v, want %v", err, integresql.ErrTemplateAlreadyInitialized)
+	}
+
+	if _, err := c.GetTestDatabase(ctx, "hash"); !errors.Is(err, integresql.ErrManagerNotReady) {
+		t.Errorf("invalid error getting test database of unfinalized template, got %v, want %v", err, integresql.ErrManagerNotReady)
+	}
+
+	if err := c.FinalizeTemplate(ctx, "hash"); err != nil {
+		t.Fatalf("failed to finalize template: %v", err)
+	}
+
+	test1, err := c.GetTestDatabase(ctx, "hash")
+	if err != nil {
+		t.Fatalf("failed to get test database: %v", err)
+	}
+
+	test2, err := c.GetTestDatabase(ctx, "hash")
+	if err != nil {
+		t.Fatalf("failed to get second test database: %v", err)
+	}
+
+	if test1.ID != 0 || test2.ID != 1 {
+		t.Errorf("invalid test database IDs, got %d and %d, want %d and %d", test1.ID, test2.ID, 0, 1)
+	}
+
+	if !reflect.DeepEqual(test2.Config, integresqlmock.TestConfig("hash", 1)) {
+		t.Errorf("invalid test database config, got %+v, want %+v", test2.Config, integresqlmock.TestConfig("hash", 1))
+	}
+
+	if err := c.ReturnTestDatabase(ctx, "hash", test1.ID); err != nil {
+		t.Fatalf("failed to return test database: %v", err)
+	}
+
+	test3, err := c.GetTestDatabase(ctx, "hash")
+	if err != nil {
+		t.Fatalf("failed to get third test database: %v", err)
+	}
+
+	if test3.ID != test1.ID {
+		t.Errorf("invalid test database ID, got %d, want the returned %d", test3.ID, test1.ID)
+	}
+
+	if err := c.ReturnTestDatabase(ctx, "hash", test2.ID); err != nil {
+		t.Fatalf("failed to return second test database: %v", err)
+	}
+
+	stats, err := c.TemplateStats(ctx, "hash")
+	if err != nil {
+		t.Fatalf("failed to get template stats: %v", err)
+	}
+
+	if want := (models.TemplatePoolStats{Ready: 1, InUse: 1}); stats != want {
+		t.Errorf("invalid template stats, got %+v, want %+v", stats, want)
+	}
+
+	if err := c.ResetTemplate(ctx, "hash"); err != nil {
+		t.Fatalf("failed to reset template: %v", err)
+	}
+
+	if stats, err := c.TemplateStats(ctx, "hash"); err != nil || stats != (models.TemplatePoolStats{}) {
+		t.Errorf("invalid template stats after reset, got %+v (%v), want empty ones", stats, err)
+	}
+
+	if err := c.RecreateTestDatabase(ctx, "hash", 99); !errors.Is(err, integresql.ErrTemplateNotFound) {
+		t.Errorf("invalid error, got %v, want %v", err, integresql.ErrTemplateNotFound)
+	}
+
+	templates, err := c.ListTemplates(ctx)
+	if err != nil {
+		t.Fatalf("failed to list templates: %v", err)
+	}
+
+	if len(templates) != 1 || templates[0].TemplateHash != "hash" {
+		t.Errorf("invalid templates, got %+v, want a single template %q", templates, "hash")
+	}
+
+	if err := c.DiscardTemplate(ctx, "hash"); err != nil {
+		t.Fatalf("failed to discard template: %v", err)
+	}
+
+	if _, err := c.GetTestDatabase(ctx, "hash"); !errors.Is(err, integresql.ErrTemplateNotFound) {
+		t.Errorf("invalid error, got %v, want %v", err, integresql.ErrTemplateNotFound)
+	}
+}
+
+func TestServerFailCall(t *testing.T) {
+	t.Parallel()
+
+	s := integresqlmock.NewServer()
+	defer s.Close()
+
+	s.FailCall(3, http.StatusServiceUnavailable)
+
+	c := newClient(t, s)
+	ctx := context.Background()
+
+	for i := 1; i <= 4; i++ {
+		ready, err := c.IsReady(ctx)
+		if err != nil {
+			t.Fatalf("failed to query manager health: %v", err)
+		}
+
+		if want := i != 3; ready != want {
+			t.Errorf("invalid readiness of call %d, got %v, want %v", i, ready, want)
+		}
+	}
+
+	if n := s.Calls(); n != 4 {
+		t.Errorf("invalid number of calls, got %d, want %d", n, 4)
+	}
+}
@@ -0,0 +1,53 @@
+package integresqlotel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/allaboutapps/integresql-client-go"
+)
+
+type tracer struct {
+	tracer trace.Tracer
+}
+
+// NewTracer creates an integresql.Tracer starting an OpenTelemetry span named after the client method
+// (e.g. integresql.InitializeTemplate) for each operation sent to the manager.
+func NewTracer(t trace.Tracer) integresql.Tracer {
+	return &tracer{tracer: t}
+}
+
+// WithTracer configures the client to start an OpenTelemetry span for each operation sent to the manager.
+func WithTracer(t trace.Tracer) integresql.Option {
+	return integresql.WithTracer(NewTracer(t))
+}
+
+func (t *tracer) StartSpan(ctx context.Context, op integresql.Operation) (context.Context, func(statusCode int, err error)) {
+	attrs := []attribute.KeyValue{}
+	if len(op.Hash) > 0 {
+		attrs = append(attrs, attribute.String("integresql.template_hash", op.Hash))
+	}
+	if op.TestID != 0 {
+		attrs = append(attrs, attribute.Int("integresql.test_id", int(op.TestID)))
+	}
+
+	ctx, span := t.tracer.Start(ctx, "integresql."+op.Name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+
+	return ctx, func(statusCode int, err error) {
+		if statusCode != 0 {
+			span.SetAttributes(attribute.Int("http.status_code", statusCode))
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		} else if statusCode >= 500 {
+			span.SetStatus(codes.Error, "")
+		}
+
+		span.End()
+	}
+}
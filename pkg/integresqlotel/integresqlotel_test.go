@@ -0,0 +1,73 @@
+package integresqlotel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/allaboutapps/integresql-client-go"
+)
+
+func TestTracer(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	c, err := integresql.NewClientWithOptions(
+		integresql.WithBaseURL(srv.URL+"/api"),
+		integresql.WithAPIVersion("v1"),
+		WithTracer(provider.Tracer("test")),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	ctx, span := provider.Tracer("test").Start(context.Background(), "parent")
+	parent := span.SpanContext()
+
+	if err := c.FinalizeTemplate(ctx, "hash"); err != nil {
+		t.Fatalf("failed to finalize template: %v", err)
+	}
+	span.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("invalid number of spans, got %d, want %d", len(spans), 2)
+	}
+
+	s := spans[0]
+	if s.Name() != "integresql.FinalizeTemplate" {
+		t.Errorf("invalid span name, got %q, want %q", s.Name(), "integresql.FinalizeTemplate")
+	}
+
+	if s.Parent().SpanID() != parent.SpanID() {
+		t.Errorf("invalid parent span, got %v, want %v", s.Parent().SpanID(), parent.SpanID())
+	}
+
+	want := map[attribute.Key]attribute.Value{
+		"integresql.template_hash": attribute.StringValue("hash"),
+		"http.status_code":         attribute.IntValue(http.StatusNoContent),
+	}
+	for _, attr := range s.Attributes() {
+		if v, ok := want[attr.Key]; ok {
+			if v != attr.Value {
+				t.Errorf("invalid attribute %q, got %v, want %v", attr.Key, attr.Value.Emit(), v.Emit())
+			}
+			delete(want, attr.Key)
+		}
+	}
+	for k := range want {
+		t.Errorf("missing attribute %q", k)
+	}
+}
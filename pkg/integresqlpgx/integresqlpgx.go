@@ -0,0 +1,36 @@
+package integresqlpgx
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/allaboutapps/integresql-client-go"
+	"github.com/allaboutapps/integresql-client-go/pkg/models"
+)
+
+// SetupTemplateWithPgxPool initializes the template identified by hash, passes a connection pool to the template
+// database to init and finalizes the template afterwards, see integresql.SetupTemplateWithConnection. Already
+// initialized templates are skipped. The template is discarded if connecting to it or init fails and the client's
+// DiscardTemplateOnInitError is enabled.
+func SetupTemplateWithPgxPool(ctx context.Context, c *integresql.Client, hash string, init func(pool *pgxpool.Pool) error) error {
+	var pool *pgxpool.Pool
+
+	connect := func(ctx context.Context, config models.DatabaseConfig) (func(ctx context.Context) error, func(), error) {
+		poolConfig, err := pgxpool.ParseConfig(config.ConnectionURL())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		pool, err = pgxpool.NewWithConfig(ctx, poolConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return pool.Ping, pool.Close, nil
+	}
+
+	return c.SetupTemplateWithConnection(ctx, hash, connect, func(ctx context.Context) error {
+		return init(pool)
+	})
+}
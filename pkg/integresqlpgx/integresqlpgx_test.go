@@ -0,0 +1,44 @@
+package integresqlpgx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/allaboutapps/integresql-client-go"
+)
+
+func TestSetupTemplateWithPgxPool(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := integresql.DefaultClientFromEnv()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	hash := "hashinghashpgx1"
+
+	if err := SetupTemplateWithPgxPool(ctx, c, hash, func(pool *pgxpool.Pool) error {
+		_, err := pool.Exec(ctx, `CREATE TABLE pilots (id int NOT NULL, "name" text NOT NULL)`)
+		return err
+	}); err != nil {
+		t.Fatalf("failed to setup template database for hash %q: %v", hash, err)
+	}
+
+	test, err := c.GetTestDatabase(ctx, hash)
+	if err != nil {
+		t.Fatalf("failed to get test database: %v", err)
+	}
+	defer c.ReturnTestDatabase(ctx, hash, test.ID) //nolint:errcheck
+
+	pool, err := pgxpool.New(ctx, test.Config.ConnectionURL())
+	if err != nil {
+		t.Fatalf("failed to open test database pool: %v", err)
+	}
+	defer pool.Close()
+
+	if _, err := pool.Exec(ctx, `INSERT INTO pilots (id, "name") VALUES (1, 'Mario')`); err != nil {
+		t.Fatalf("failed to insert into test database: %v", err)
+	}
+}
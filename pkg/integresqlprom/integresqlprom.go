@@ -0,0 +1,67 @@
+package integresqlprom
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/allaboutapps/integresql-client-go"
+)
+
+type metrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// NewMetrics creates an integresql.Metrics recording the number of requests sent to the manager by operation
+// and status code as well as their duration, registering its collectors with reg. Collectors already registered
+// (e.g. by another client using the same registerer) are reused. Pass the returned metrics to the client via
+// integresql.WithMetrics or ClientConfig.Metrics.
+func NewMetrics(reg prometheus.Registerer) (integresql.Metrics, error) {
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "integresql",
+		Subsystem: "client",
+		Name:      "requests_total",
+		Help:      "Total number of requests sent to the IntegreSQL manager by operation and status code.",
+	}, []string{"operation", "code"})
+
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "integresql",
+		Subsystem: "client",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of requests sent to the IntegreSQL manager by operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	if err := reg.Register(requests); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			return nil, err
+		}
+		requests = are.ExistingCollector.(*prometheus.CounterVec)
+	}
+
+	if err := reg.Register(duration); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			return nil, err
+		}
+		duration = are.ExistingCollector.(*prometheus.HistogramVec)
+	}
+
+	return &metrics{
+		requests: requests,
+		duration: duration,
+	}, nil
+}
+
+func (m *metrics) ObserveRequest(op integresql.Operation, info integresql.RequestInfo) {
+	code := "error"
+	if info.StatusCode != 0 {
+		code = strconv.Itoa(info.StatusCode)
+	}
+
+	m.requests.WithLabelValues(op.Name, code).Inc()
+	m.duration.WithLabelValues(op.Name).Observe(info.Duration.Seconds())
+}
@@ -0,0 +1,94 @@
+package integresqlprom
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/allaboutapps/integresql-client-go"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+
+	m, err := NewMetrics(reg)
+	if err != nil {
+		t.Fatalf("failed to create metrics: %v", err)
+	}
+
+	c, err := integresql.NewClientWithOptions(
+		integresql.WithBaseURL(srv.URL+"/api"),
+		integresql.WithAPIVersion("v1"),
+		integresql.WithMetrics(m),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if err := c.FinalizeTemplate(ctx, "hash"); err != nil {
+		t.Fatalf("failed to finalize template: %v", err)
+	}
+
+	if err := c.FinalizeTemplate(ctx, "hash"); err != nil {
+		t.Fatalf("failed to finalize template: %v", err)
+	}
+
+	if err := c.DiscardTemplate(ctx, "hash"); err == nil {
+		t.Fatal("discarding template should have failed")
+	}
+
+	want := `
+# HELP integresql_client_requests_total Total number of requests sent to the IntegreSQL manager by operation and status code.
+# TYPE integresql_client_requests_total counter
+integresql_client_requests_total{code="204",operation="FinalizeTemplate"} 2
+integresql_client_requests_total{code="404",operation="DiscardTemplate"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "integresql_client_requests_total"); err != nil {
+		t.Errorf("invalid request metrics: %v", err)
+	}
+
+	if n := testutil.CollectAndCount(reg, "integresql_client_request_duration_seconds"); n != 2 {
+		t.Errorf("invalid number of duration metrics, got %d, want %d", n, 2)
+	}
+
+	// registering with the same registerer again reuses the existing collectors
+	if _, err := NewMetrics(reg); err != nil {
+		t.Errorf("failed to create metrics a second time: %v", err)
+	}
+}
+
+func TestNewMetricsConflictingCollector(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewRegistry()
+
+	// a collector with the same name but different labels cannot be reused and must be reported instead of panicking
+	reg.MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "integresql",
+		Subsystem: "client",
+		Name:      "requests_total",
+		Help:      "Total number of requests sent to the IntegreSQL manager by operation and status code.",
+	}, []string{"operation"}))
+
+	if _, err := NewMetrics(reg); err == nil {
+		t.Error("invalid error, got nil, want conflicting collector to be reported")
+	}
+}
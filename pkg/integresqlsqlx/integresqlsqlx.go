@@ -0,0 +1,37 @@
+package integresqlsqlx
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/allaboutapps/integresql-client-go"
+	"github.com/allaboutapps/integresql-client-go/pkg/models"
+)
+
+// SetupTemplateWithSqlx works like the client's SetupTemplateWithDBClient, but passes the connection to the
+// template database to init as *sqlx.DB.
+func SetupTemplateWithSqlx(ctx context.Context, c *integresql.Client, hash string, init func(db *sqlx.DB) error) error {
+	return c.SetupTemplateWithDBClient(ctx, hash, func(db *sql.DB) error {
+		return init(sqlx.NewDb(db, c.Config().DriverName))
+	})
+}
+
+// GetTestDatabaseSqlx retrieves a test database for the template identified by hash and returns it alongside a
+// pinged connection to it, opened using the client's configured DriverName. The caller is responsible for closing
+// the connection and returning the test database. If connecting fails, the retrieved test database is returned
+// alongside the error, allowing the caller to return it.
+func GetTestDatabaseSqlx(ctx context.Context, c *integresql.Client, hash string) (models.TestDatabase, *sqlx.DB, error) {
+	test, err := c.GetTestDatabase(ctx, hash)
+	if err != nil {
+		return test, nil, err
+	}
+
+	db, err := sqlx.ConnectContext(ctx, c.Config().DriverName, test.Config.ConnectionString())
+	if err != nil {
+		return test, nil, err
+	}
+
+	return test, db, nil
+}
@@ -0,0 +1,51 @@
+package integresqlsqlx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/allaboutapps/integresql-client-go"
+)
+
+func TestSetupTemplateWithSqlx(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := integresql.DefaultClientFromEnv()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	hash := "hashinghashsqlx1"
+
+	if err := SetupTemplateWithSqlx(ctx, c, hash, func(db *sqlx.DB) error {
+		if _, err := db.ExecContext(ctx, `CREATE TABLE pilots (id int NOT NULL, "name" text NOT NULL)`); err != nil {
+			return err
+		}
+
+		_, err := db.ExecContext(ctx, `INSERT INTO pilots (id, "name") VALUES (1, 'Mario')`)
+		return err
+	}); err != nil {
+		t.Fatalf("failed to setup template database for hash %q: %v", hash, err)
+	}
+
+	test, db, err := GetTestDatabaseSqlx(ctx, c, hash)
+	if err != nil {
+		t.Fatalf("failed to get test database: %v", err)
+	}
+	defer c.ReturnTestDatabase(ctx, hash, test.ID) //nolint:errcheck
+	defer db.Close()
+
+	var pilots []struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+	if err := db.SelectContext(ctx, &pilots, `SELECT id, "name" FROM pilots`); err != nil {
+		t.Fatalf("failed to select pilots: %v", err)
+	}
+
+	if len(pilots) != 1 || pilots[0].Name != "Mario" {
+		t.Errorf("invalid pilots, got %+v, want a single pilot named %q", pilots, "Mario")
+	}
+}
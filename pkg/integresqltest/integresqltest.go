@@ -0,0 +1,46 @@
+package integresqltest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/allaboutapps/integresql-client-go"
+)
+
+// GetDatabase retrieves a test database for the template identified by hash and returns an open connection to it.
+// The connection is closed and the test database released (see ReleaseTestDatabase) once the test and all its subtests completed.
+// Any failure while retrieving or connecting to the test database is fatal for the test.
+func GetDatabase(t testing.TB, c *integresql.Client, hash string) *sql.DB {
+	t.Helper()
+
+	ctx := context.Background()
+
+	test, err := c.GetTestDatabase(ctx, hash)
+	if err != nil {
+		t.Fatalf("failed to get test database: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := c.ReleaseTestDatabase(ctx, hash, test.ID); err != nil {
+			t.Errorf("failed to release test database %d: %v", test.ID, err)
+		}
+	})
+
+	db, err := sql.Open(c.Config().DriverName, test.Config.ConnectionString())
+	if err != nil {
+		t.Fatalf("failed to open test database connection: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Logf("failed to close test database connection: %v", err)
+		}
+	})
+
+	if err := db.PingContext(ctx); err != nil {
+		t.Fatalf("failed to ping test database connection: %v", err)
+	}
+
+	return db
+}
@@ -0,0 +1,33 @@
+package integresqltest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/allaboutapps/integresql-client-go"
+)
+
+func TestGetDatabase(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := integresql.DefaultClientFromEnv()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	hash := "hashinghashtest1"
+
+	if err := c.SetupTemplateWithDBClient(ctx, hash, func(db *sql.DB) error {
+		_, err := db.ExecContext(ctx, `CREATE TABLE pilots (id int NOT NULL, "name" text NOT NULL)`)
+		return err
+	}); err != nil {
+		t.Fatalf("failed to setup template database for hash %q: %v", hash, err)
+	}
+
+	db := GetDatabase(t, c, hash)
+
+	if _, err := db.ExecContext(ctx, `INSERT INTO pilots (id, "name") VALUES (1, 'Mario')`); err != nil {
+		t.Fatalf("failed to insert into test database: %v", err)
+	}
+}
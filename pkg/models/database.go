@@ -1,5 +1,6 @@
 package models
 
+// Database is the JSON representation of a template or test database returned by the IntegreSQL API.
 type Database struct {
 	TemplateHash string         `json:"templateHash"`
 	Config       DatabaseConfig `json:"config"`
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDatabaseJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		payload string
+		target  func() interface{}
+	}{
+		{
+			name:    "TemplateDatabase",
+			payload: `{"database":{"templateHash":"hash","config":{"host":"localhost","port":5432,"username":"user","password":"pass","database":"integresql_template_hash"}}}`,
+			target:  func() interface{} { return &TemplateDatabase{} },
+		},
+		{
+			name:    "TestDatabase",
+			payload: `{"database":{"templateHash":"hash","config":{"host":"localhost","port":5432,"username":"user","password":"pass","database":"integresql_test_hash_001","additionalParams":{"connect_timeout":"10"}}},"id":1}`,
+			target:  func() interface{} { return &TestDatabase{} },
+		},
+		{
+			name:    "TestDatabaseSSLMode",
+			payload: `{"database":{"templateHash":"hash","config":{"host":"localhost","port":5432,"username":"user","password":"pass","database":"integresql_test_hash_002","sslMode":"require","applicationName":"tests"}},"id":2}`,
+			target:  func() interface{} { return &TestDatabase{} },
+		},
+		{
+			name:    "TemplatePoolStats",
+			payload: `{"ready":3,"dirty":1,"inUse":4}`,
+			target:  func() interface{} { return &TemplatePoolStats{} },
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			v := tt.target()
+
+			dec := json.NewDecoder(strings.NewReader(tt.payload))
+			dec.DisallowUnknownFields()
+			if err := dec.Decode(v); err != nil {
+				t.Fatalf("failed to decode payload: %v", err)
+			}
+
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				t.Fatalf("failed to encode payload: %v", err)
+			}
+
+			var got, want interface{}
+			if err := json.Unmarshal(encoded, &got); err != nil {
+				t.Fatalf("failed to decode re-encoded payload: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.payload), &want); err != nil {
+				t.Fatalf("failed to decode payload: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("invalid JSON after round trip, got %s, want %s", encoded, tt.payload)
+			}
+		})
+	}
+}
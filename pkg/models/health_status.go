@@ -0,0 +1,9 @@
+package models
+
+// HealthStatus holds the details reported by the healthcheck endpoint of the manager.
+type HealthStatus struct {
+	Version   string            `json:"version"`   // version of the IntegreSQL server
+	Ready     bool              `json:"ready"`     // whether the manager is ready to serve requests
+	Templates int               `json:"templates"` // number of templates known to the manager
+	Pool      TemplatePoolStats `json:"pool"`      // test databases summed up over all templates
+}
@@ -1,5 +1,7 @@
 package models
 
+// TemplateDatabase is returned by the IntegreSQL API when initializing or retrieving a template, e.g.
+// {"database":{"templateHash":"...","config":{...}}}.
 type TemplateDatabase struct {
 	Database `json:"database"`
 }
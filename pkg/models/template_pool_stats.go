@@ -0,0 +1,8 @@
+package models
+
+// TemplatePoolStats holds the number of test databases in the pool of a template by state.
+type TemplatePoolStats struct {
+	Ready int `json:"ready"` // test databases ready to be handed out
+	Dirty int `json:"dirty"` // returned test databases waiting to be recreated
+	InUse int `json:"inUse"` // test databases currently handed out to tests
+}
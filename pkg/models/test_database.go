@@ -1,7 +1,13 @@
 package models
 
+// TestDatabaseID identifies a test database within the pool of its template. IDs used to be passed as plain int,
+// convert existing int values via TestDatabaseID(id).
+type TestDatabaseID int
+
+// TestDatabase is returned by the IntegreSQL API when retrieving a test database, e.g.
+// {"database":{"templateHash":"...","config":{...}},"id":1}.
 type TestDatabase struct {
 	Database `json:"database"`
 
-	ID int `json:"id"`
+	ID TestDatabaseID `json:"id"`
 }
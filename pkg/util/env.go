@@ -3,6 +3,7 @@ package util
 import (
 	"os"
 	"strconv"
+	"time"
 )
 
 func GetEnv(key string, defaultVal string) string {
@@ -32,3 +33,13 @@ func GetEnvAsBool(key string, defaultVal bool) bool {
 
 	return defaultVal
 }
+
+func GetEnvAsDuration(key string, defaultVal time.Duration) time.Duration {
+	strVal := GetEnv(key, "")
+
+	if val, err := time.ParseDuration(strVal); err == nil {
+		return val
+	}
+
+	return defaultVal
+}
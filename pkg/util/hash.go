@@ -2,10 +2,17 @@ package util
 
 import (
 	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
+	"io"
+	"io/fs"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"sync"
@@ -155,3 +162,116 @@ func GetTemplateHash(paths ...string) (string, error) {
 
 	return fmt.Sprintf("%x", h.Sum(nil)), nil
 }
+
+// HashDirectory returns a SHA-256 sum of a directory suitable as template hash, calculated over the relative
+// paths and contents of all contained regular files. Files are hashed in order of their slash-separated path
+// relative to dir, each contributing its path and its content as parts (see HashContents), so the hash is
+// stable across machines and independent of the location of dir. For a directory containing the files
+// "a.sql" and "b/c.sql", the hash is equal to HashContents([]byte("a.sql"), a, []byte("b/c.sql"), c).
+// Files and directories whose relative path or base name match any of the exclude patterns (see path.Match)
+// are skipped.
+func HashDirectory(dir string, exclude ...string) (string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if rel == "." {
+			return nil
+		}
+
+		for _, pattern := range exclude {
+			excluded, err := matchHashExclude(pattern, rel)
+			if err != nil {
+				return err
+			}
+
+			if excluded {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+
+		if d.Type().IsRegular() {
+			paths = append(paths, rel)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sort.Strings(paths)
+
+	h := sha256.New()
+	for _, rel := range paths {
+		data, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil {
+			return "", err
+		}
+
+		writeHashPart(h, []byte(rel))
+		writeHashPart(h, data)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HashContents returns a SHA-256 sum of the given parts suitable as template hash, e.g. for migrations generated
+// in memory. The order of parts is significant. The hash is calculated as the hex encoded SHA-256 digest over
+// all parts, each prefixed with its length in bytes as 8 byte big-endian unsigned integer, allowing clients in
+// other languages to compute matching hashes.
+func HashContents(parts ...[]byte) string {
+	h := sha256.New()
+	for _, p := range parts {
+		writeHashPart(h, p)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// HashReaders returns the same hash as HashContents would for the contents read from each of the given readers.
+// Readers are read in the given order until EOF, buffering each one in memory to determine its length.
+func HashReaders(readers ...io.Reader) (string, error) {
+	h := sha256.New()
+	for _, r := range readers {
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return "", err
+		}
+
+		writeHashPart(h, data)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// matchHashExclude reports whether the slash-separated relative path or its base name match pattern.
+func matchHashExclude(pattern string, rel string) (bool, error) {
+	matched, err := path.Match(pattern, rel)
+	if err != nil || matched {
+		return matched, err
+	}
+
+	return path.Match(pattern, path.Base(rel))
+}
+
+// writeHashPart writes the length of p as 8 byte big-endian unsigned integer followed by p itself to h,
+// ensuring consecutive parts cannot be shifted into each other without changing the resulting hash.
+func writeHashPart(h hash.Hash, p []byte) {
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(p)))
+
+	h.Write(length[:])
+	h.Write(p)
+}
@@ -1,7 +1,10 @@
 package util
 
 import (
+	"io/ioutil"
+	"os"
 	"path"
+	"strings"
 	"testing"
 )
 
@@ -55,3 +58,129 @@ func TestHashUtilGetTemplateHash(t *testing.T) {
 		t.Errorf("invalid template hash, got %q, want %q", hash, expected)
 	}
 }
+
+func TestHashUtilHashDirectory(t *testing.T) {
+	t.Parallel()
+
+	tmp := setupTestDir(t)
+	defer cleanupTestDir(t, tmp)
+
+	hash, err := HashDirectory(tmp)
+	if err != nil {
+		t.Fatalf("failed to hash directory: %v", err)
+	}
+
+	if len(hash) != 64 {
+		t.Errorf("invalid hash length, got %d, want %d", len(hash), 64)
+	}
+
+	// the same contents at a different location must result in the same hash
+	other := setupTestDir(t)
+	defer cleanupTestDir(t, other)
+
+	otherHash, err := HashDirectory(other)
+	if err != nil {
+		t.Fatalf("failed to hash directory: %v", err)
+	}
+
+	if otherHash != hash {
+		t.Errorf("invalid directory hash, got %q, want %q", otherHash, hash)
+	}
+
+	// renaming a file must change the hash, even though the contents stay the same
+	if err := os.Rename(path.Join(other, "1.txt"), path.Join(other, "4.txt")); err != nil {
+		t.Fatalf("failed to rename test file: %v", err)
+	}
+
+	renamedHash, err := HashDirectory(other)
+	if err != nil {
+		t.Fatalf("failed to hash directory: %v", err)
+	}
+
+	if renamedHash == hash {
+		t.Errorf("invalid directory hash, got %q, want it to differ after renaming a file", renamedHash)
+	}
+}
+
+func TestHashUtilHashDirectoryExclude(t *testing.T) {
+	t.Parallel()
+
+	tmp := setupTestDir(t)
+	defer cleanupTestDir(t, tmp)
+
+	hash, err := HashDirectory(tmp, "*.txt")
+	if err != nil {
+		t.Fatalf("failed to hash directory: %v", err)
+	}
+
+	if err := os.Mkdir(path.Join(tmp, "docs"), 0755); err != nil {
+		t.Fatalf("failed to create test dir: %v", err)
+	}
+
+	if err := ioutil.WriteFile(path.Join(tmp, "docs", "README.sql"), []byte("-- docs"), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	if err := ioutil.WriteFile(path.Join(tmp, "5.txt"), []byte("unrelated"), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	excludedHash, err := HashDirectory(tmp, "*.txt", "docs")
+	if err != nil {
+		t.Fatalf("failed to hash directory: %v", err)
+	}
+
+	if excludedHash != hash {
+		t.Errorf("invalid directory hash, got %q, want %q", excludedHash, hash)
+	}
+
+	if _, err := HashDirectory(tmp, "["); err == nil {
+		t.Error("invalid error, got nil, want bad pattern error")
+	}
+}
+
+func TestHashUtilHashContents(t *testing.T) {
+	t.Parallel()
+
+	// reference value, allowing clients in other languages to verify their implementation
+	expected := "fac9570d9f82d2c4871fc44212c8d308628d9091dac74d2d52daed25ec76b5f2"
+	if hash := HashContents([]byte("1.sql"), []byte("SELECT 1;")); hash != expected {
+		t.Errorf("invalid contents hash, got %q, want %q", hash, expected)
+	}
+
+	// shifting bytes between parts must change the hash
+	if hash := HashContents([]byte("1.sq"), []byte("lSELECT 1;")); hash == expected {
+		t.Errorf("invalid contents hash, got %q, want it to differ", hash)
+	}
+
+	hash, err := HashReaders(strings.NewReader("1.sql"), strings.NewReader("SELECT 1;"))
+	if err != nil {
+		t.Fatalf("failed to hash readers: %v", err)
+	}
+
+	if hash != expected {
+		t.Errorf("invalid readers hash, got %q, want %q", hash, expected)
+	}
+}
+
+func TestHashUtilHashContentsMatchesHashDirectory(t *testing.T) {
+	t.Parallel()
+
+	tmp := setupTestDir(t)
+	defer cleanupTestDir(t, tmp)
+
+	expected, err := HashDirectory(tmp)
+	if err != nil {
+		t.Fatalf("failed to hash directory: %v", err)
+	}
+
+	hash := HashContents(
+		[]byte("1.txt"), []byte("hello there"),
+		[]byte("2.sql"), []byte("SELECT 1;"),
+		[]byte("3.txt"), []byte("general kenobi"),
+	)
+
+	if hash != expected {
+		t.Errorf("invalid contents hash, got %q, want %q", hash, expected)
+	}
+}
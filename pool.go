@@ -0,0 +1,136 @@
+package integresql
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/allaboutapps/integresql-client-go/pkg/models"
+)
+
+var ErrPoolClosed = errors.New("pool is closed")
+
+type poolResult struct {
+	test models.TestDatabase
+	err  error
+}
+
+// Pool keeps a client-side buffer of test databases for a single template, prefetching them in the background
+// to amortize the latency of retrieving test databases from the manager.
+type Pool struct {
+	client  *Client
+	hash    string
+	results chan poolResult
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewPool creates a new pool for the template identified by hash, immediately starting to prefetch size test databases.
+// A pool always buffers at least one test database, a size below 1 is treated as 1.
+func NewPool(c *Client, hash string, size int) *Pool {
+	if size < 1 {
+		size = 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p := &Pool{
+		client:  c,
+		hash:    hash,
+		results: make(chan poolResult, size),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+
+	for i := 0; i < size; i++ {
+		p.refill()
+	}
+
+	return p
+}
+
+// Get retrieves a prefetched test database, blocking until one is available or the context is done.
+// The returned func releases the test database via ReleaseTestDatabase and prefetches a new one,
+// it must be called exactly once.
+func (p *Pool) Get(ctx context.Context) (models.TestDatabase, func() error, error) {
+	select {
+	case <-ctx.Done():
+		return models.TestDatabase{}, nil, ctx.Err()
+	case <-p.ctx.Done():
+		return models.TestDatabase{}, nil, ErrPoolClosed
+	case res, ok := <-p.results:
+		if !ok {
+			return models.TestDatabase{}, nil, ErrPoolClosed
+		}
+
+		if res.err != nil {
+			// retry the failed prefetch so the pool keeps its size
+			p.refill()
+			return models.TestDatabase{}, nil, res.err
+		}
+
+		var once sync.Once
+		release := func() error {
+			var err error
+			once.Do(func() {
+				err = p.client.ReleaseTestDatabase(context.Background(), p.hash, res.test.ID)
+				p.refill()
+			})
+
+			return err
+		}
+
+		return res.test, release, nil
+	}
+}
+
+// Close stops prefetching and returns all test databases currently buffered by the pool.
+// Test databases handed out via Get must still be released by the caller.
+func (p *Pool) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrPoolClosed
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	p.cancel()
+	p.wg.Wait()
+	close(p.results)
+
+	var firstErr error
+	for res := range p.results {
+		if res.err != nil {
+			continue
+		}
+
+		if err := p.client.ReturnTestDatabase(ctx, p.hash, res.test.ID); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func (p *Pool) refill() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		test, err := p.client.GetTestDatabase(p.ctx, p.hash)
+		p.results <- poolResult{test: test, err: err}
+	}()
+}
@@ -0,0 +1,169 @@
+package integresql
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type stubManager struct {
+	nextID   int32
+	acquired int32
+
+	mu        sync.Mutex
+	returned  []string
+	recreated []string
+}
+
+func (m *stubManager) handle(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		atomic.AddInt32(&m.acquired, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":%d,"database":{"templateHash":"hash"}}`, atomic.AddInt32(&m.nextID, 1))
+	case http.MethodDelete:
+		m.mu.Lock()
+		m.returned = append(m.returned, r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+		m.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodPost:
+		m.mu.Lock()
+		m.recreated = append(m.recreated, strings.Split(r.URL.Path, "/")[5])
+		m.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (m *stubManager) returnedCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.returned)
+}
+
+func (m *stubManager) recreatedCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.recreated)
+}
+
+func TestPool(t *testing.T) {
+	t.Parallel()
+
+	m := &stubManager{}
+	c := newStubClient(t, m.handle)
+
+	p := NewPool(c, "hash", 3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	test, release, err := p.Get(ctx)
+	if err != nil {
+		t.Fatalf("failed to get test database from pool: %v", err)
+	}
+
+	if test.TemplateHash != "hash" {
+		t.Errorf("invalid template hash, got %q, want %q", test.TemplateHash, "hash")
+	}
+
+	if err := release(); err != nil {
+		t.Fatalf("failed to release test database: %v", err)
+	}
+
+	// releasing more than once is a no-op
+	if err := release(); err != nil {
+		t.Fatalf("failed to release test database a second time: %v", err)
+	}
+
+	// wait for the pool to refill its buffer
+	for atomic.LoadInt32(&m.acquired) < 4 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := p.Close(ctx); err != nil {
+		t.Fatalf("failed to close pool: %v", err)
+	}
+
+	// released test databases are recreated, prefetched ones were never used and are returned as is
+	if n := m.recreatedCount(); n != 1 {
+		t.Errorf("invalid number of recreated test databases, got %d, want %d", n, 1)
+	}
+
+	if n := m.returnedCount(); n != 3 {
+		t.Errorf("invalid number of returned test databases, got %d, want %d", n, 3)
+	}
+
+	if _, _, err := p.Get(ctx); err != ErrPoolClosed {
+		t.Errorf("invalid error, got %v, want %v", err, ErrPoolClosed)
+	}
+}
+
+func TestPoolGetBlocksUntilContextDone(t *testing.T) {
+	t.Parallel()
+
+	m := &stubManager{}
+	c := newStubClient(t, m.handle)
+
+	p := NewPool(c, "hash", 1)
+	defer p.Close(context.Background()) //nolint:errcheck
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, release, err := p.Get(ctx)
+	if err != nil {
+		t.Fatalf("failed to get test database from pool: %v", err)
+	}
+	defer release() //nolint:errcheck
+
+	shortCtx, shortCancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer shortCancel()
+
+	if _, _, err := p.Get(shortCtx); err != context.DeadlineExceeded {
+		t.Errorf("invalid error, got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestPoolMinimumSize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		size int
+	}{
+		{name: "zero", size: 0},
+		{name: "negative", size: -1},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m := &stubManager{}
+			c := newStubClient(t, m.handle)
+
+			p := NewPool(c, "hash", tt.size)
+			defer p.Close(context.Background()) //nolint:errcheck
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			_, release, err := p.Get(ctx)
+			if err != nil {
+				t.Fatalf("failed to get test database from pool: %v", err)
+			}
+
+			if err := release(); err != nil {
+				t.Errorf("failed to release test database: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package integresql
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// ExecSQLStream reads SQL statements from r and executes them one by one against db, e.g. to initialize a template
+// from a large schema dump without loading it into memory as a whole. Statements are split on semicolons outside
+// of quoted strings, quoted identifiers, dollar-quoted bodies (e.g. of functions) and comments, the latter being
+// stripped before execution. All statements are executed on a single connection, so session state like
+// SET search_path or temporary tables carries over between them.
+func ExecSQLStream(ctx context.Context, db *sql.DB, r io.Reader) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	s := newSQLStatementScanner(r)
+
+	for n := 1; ; n++ {
+		stmt, err := s.next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read statement %d: %w", n, err)
+		}
+
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to execute statement %d: %w", n, err)
+		}
+	}
+}
+
+// sqlStatementScanner splits a stream of SQL into single statements, keeping only the current statement in memory.
+type sqlStatementScanner struct {
+	r *bufio.Reader
+	b strings.Builder
+}
+
+func newSQLStatementScanner(r io.Reader) *sqlStatementScanner {
+	return &sqlStatementScanner{r: bufio.NewReader(r)}
+}
+
+// next returns the next non-empty statement without its terminating semicolon, or io.EOF if no statements are left.
+func (s *sqlStatementScanner) next() (string, error) {
+	s.b.Reset()
+
+	var prev rune
+	for {
+		ch, _, err := s.r.ReadRune()
+		if errors.Is(err, io.EOF) {
+			return s.statement()
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch {
+		case ch == ';':
+			if stmt, err := s.statement(); err == nil {
+				return stmt, nil
+			}
+			s.b.Reset()
+		case ch == '\'' || ch == '"':
+			s.b.WriteRune(ch)
+			// E'...' strings allow escaping the quote via backslash
+			escapes := ch == '\'' && (prev == 'E' || prev == 'e')
+			if err := s.quoted(ch, escapes); err != nil {
+				return "", err
+			}
+		case ch == '-' && s.peek('-'):
+			if err := s.lineComment(); err != nil {
+				return "", err
+			}
+			ch = '\n'
+			s.b.WriteRune(ch)
+		case ch == '/' && s.peek('*'):
+			if err := s.blockComment(); err != nil {
+				return "", err
+			}
+			ch = ' '
+			s.b.WriteRune(ch)
+		case ch == '$' && !isIdentifierRune(prev):
+			if err := s.dollarQuoted(); err != nil {
+				return "", err
+			}
+		default:
+			s.b.WriteRune(ch)
+		}
+
+		prev = ch
+	}
+}
+
+// statement returns the statement read so far, or io.EOF if it is empty.
+func (s *sqlStatementScanner) statement() (string, error) {
+	stmt := strings.TrimSpace(s.b.String())
+	if len(stmt) == 0 {
+		return "", io.EOF
+	}
+
+	return stmt, nil
+}
+
+// peek consumes the next rune if it equals ch, reporting whether it did.
+func (s *sqlStatementScanner) peek(ch rune) bool {
+	next, _, err := s.r.ReadRune()
+	if err != nil {
+		return false
+	}
+
+	if next != ch {
+		_ = s.r.UnreadRune()
+		return false
+	}
+
+	return true
+}
+
+// quoted copies a string or identifier quoted with quote, a doubled quote is part of the quoted text.
+func (s *sqlStatementScanner) quoted(quote rune, escapes bool) error {
+	for {
+		ch, _, err := s.r.ReadRune()
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+
+		s.b.WriteRune(ch)
+
+		if escapes && ch == '\\' {
+			next, _, err := s.r.ReadRune()
+			if err != nil {
+				return unexpectedEOF(err)
+			}
+			s.b.WriteRune(next)
+			continue
+		}
+
+		if ch == quote {
+			if s.peek(quote) {
+				s.b.WriteRune(quote)
+				continue
+			}
+
+			return nil
+		}
+	}
+}
+
+// lineComment skips a -- comment up to the end of the line.
+func (s *sqlStatementScanner) lineComment() error {
+	for {
+		ch, _, err := s.r.ReadRune()
+		if errors.Is(err, io.EOF) || ch == '\n' {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// blockComment skips a /* */ comment, which may be nested in Postgres.
+func (s *sqlStatementScanner) blockComment() error {
+	depth := 1
+	for depth > 0 {
+		ch, _, err := s.r.ReadRune()
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+
+		switch {
+		case ch == '/' && s.peek('*'):
+			depth++
+		case ch == '*' && s.peek('/'):
+			depth--
+		}
+	}
+
+	return nil
+}
+
+// dollarQuoted copies a $tag$ ... $tag$ quoted body after its opening $ has been read. If the $ does not start
+// a dollar quote (e.g. a positional parameter like $1), it is copied as is.
+func (s *sqlStatementScanner) dollarQuoted() error {
+	var tag strings.Builder
+	tag.WriteRune('$')
+
+	for {
+		ch, _, err := s.r.ReadRune()
+		if errors.Is(err, io.EOF) {
+			s.b.WriteString(tag.String())
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if ch == '$' {
+			tag.WriteRune(ch)
+			break
+		}
+
+		// tags follow the rules of unquoted identifiers, but must not start with a digit
+		if !isIdentifierRune(ch) || (tag.Len() == 1 && unicode.IsDigit(ch)) {
+			_ = s.r.UnreadRune()
+			s.b.WriteString(tag.String())
+			return nil
+		}
+
+		tag.WriteRune(ch)
+	}
+
+	delimiter := tag.String()
+	s.b.WriteString(delimiter)
+
+	start := s.b.Len()
+	for {
+		ch, _, err := s.r.ReadRune()
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+
+		s.b.WriteRune(ch)
+
+		if ch == '$' && s.b.Len()-start >= len(delimiter) && strings.HasSuffix(s.b.String(), delimiter) {
+			return nil
+		}
+	}
+}
+
+func isIdentifierRune(ch rune) bool {
+	return ch == '_' || unicode.IsLetter(ch) || unicode.IsDigit(ch)
+}
+
+// unexpectedEOF reports reaching the end of the stream within a quoted text or comment as io.ErrUnexpectedEOF.
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
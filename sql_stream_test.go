@@ -0,0 +1,141 @@
+package integresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSQLStatementScanner(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr error
+	}{
+		{
+			name:  "Simple",
+			input: "CREATE TABLE pilots (id int);\nINSERT INTO pilots VALUES (1);\n",
+			want:  []string{"CREATE TABLE pilots (id int)", "INSERT INTO pilots VALUES (1)"},
+		},
+		{
+			name:  "MissingTrailingSemicolon",
+			input: "SELECT 1;\nSELECT 2",
+			want:  []string{"SELECT 1", "SELECT 2"},
+		},
+		{
+			name:  "Strings",
+			input: `INSERT INTO pilots VALUES ('Mario; Luigi', 'it''s', E'a\'b;c', "semi;colon");SELECT 1;`,
+			want:  []string{`INSERT INTO pilots VALUES ('Mario; Luigi', 'it''s', E'a\'b;c', "semi;colon")`, "SELECT 1"},
+		},
+		{
+			name:  "Comments",
+			input: "-- leading; comment\nSELECT 1; /* block; /* nested; */ comment */ SELECT 2;\n-- trailing comment only;\n",
+			want:  []string{"SELECT 1", "SELECT 2"},
+		},
+		{
+			name: "DollarQuoted",
+			input: `CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql;
+CREATE FUNCTION g() RETURNS int AS $body$ BEGIN RETURN $1; END; $body$ LANGUAGE plpgsql;
+SELECT $1, a$b FROM t;`,
+			want: []string{
+				`CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql`,
+				`CREATE FUNCTION g() RETURNS int AS $body$ BEGIN RETURN $1; END; $body$ LANGUAGE plpgsql`,
+				`SELECT $1, a$b FROM t`,
+			},
+		},
+		{
+			name:    "UnterminatedString",
+			input:   "SELECT 'unterminated;",
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "UnterminatedDollarQuote",
+			input:   "SELECT $$ unterminated;",
+			wantErr: io.ErrUnexpectedEOF,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := newSQLStatementScanner(strings.NewReader(tt.input))
+
+			var got []string
+			for {
+				stmt, err := s.next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					if !errors.Is(err, tt.wantErr) {
+						t.Fatalf("invalid error, got %v, want %v", err, tt.wantErr)
+					}
+					return
+				}
+
+				got = append(got, stmt)
+			}
+
+			if tt.wantErr != nil {
+				t.Fatalf("invalid error, got nil, want %v", tt.wantErr)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("invalid statements, got %q, want %q", got, tt.want)
+			}
+
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("invalid statement %d, got %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExecSQLStream(t *testing.T) {
+	t.Parallel()
+
+	dsn := "dbname=exec_sql_stream"
+
+	db, err := sql.Open("integresql_stub", dsn)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// without idle connections, every statement not pinned to a single connection would run on a new one
+	db.SetMaxIdleConns(0)
+
+	if err := ExecSQLStream(context.Background(), db, strings.NewReader("CREATE TABLE pilots (id int);\n-- seed\nINSERT INTO pilots VALUES (1);\nSET search_path TO public;")); err != nil {
+		t.Fatalf("failed to execute SQL stream: %v", err)
+	}
+
+	want := []string{"CREATE TABLE pilots (id int)", "INSERT INTO pilots VALUES (1)", "SET search_path TO public"}
+
+	got := testStubDriver.statements(dsn)
+	if len(got) != len(want) {
+		t.Fatalf("invalid executed statements, got %q, want %q", got, want)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("invalid executed statement %d, got %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	conns := testStubDriver.connections(dsn)
+	for i := range conns {
+		if conns[i] != conns[0] {
+			t.Errorf("invalid connection of statement %d, got %d, want %d", i, conns[i], conns[0])
+		}
+	}
+}
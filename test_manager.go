@@ -0,0 +1,87 @@
+package integresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+)
+
+// TestManager manages a single template across a whole test package, lazily setting up the template on first use
+// and handing out test databases afterwards.
+type TestManager struct {
+	client *Client
+	hash   string
+	setup  func(db *sql.DB) error
+
+	mu       sync.Mutex // serializes setting up the template
+	done     bool       // template setup completed, successfully or not
+	setupErr error
+}
+
+// NewTestManager creates a new test manager for the template identified by hash, which is set up via setup (see
+// SetupTemplateWithDBClient) on the first call to Acquire.
+func NewTestManager(c *Client, hash string, setup func(db *sql.DB) error) *TestManager {
+	return &TestManager{
+		client: c,
+		hash:   hash,
+		setup:  setup,
+	}
+}
+
+// Acquire sets up the template if this is the first call (exactly once, even if called concurrently), retrieves
+// a test database and returns a pinged connection pool to it via OpenTestDatabase. If setting up the template
+// failed, all calls return the same error, unless it failed because ctx was cancelled or its deadline exceeded, in
+// which case the next call retries the setup. Test databases that cannot be connected to are returned right away.
+// The returned func releases the test database via ReleaseTestDatabase and must be called exactly once, the
+// connection pool is owned by the client and must not be closed.
+func (m *TestManager) Acquire(ctx context.Context) (*sql.DB, func() error, error) {
+	if err := m.setupTemplate(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	test, db, err := m.client.OpenTestDatabase(ctx, m.hash)
+	if err != nil {
+		// OpenTestDatabase returns the test database if it was retrieved, but could not be connected to
+		if len(test.TemplateHash) > 0 {
+			if returnErr := m.client.ReturnTestDatabase(ctx, m.hash, test.ID); returnErr != nil {
+				err = errors.Join(err, returnErr)
+			}
+		}
+
+		return nil, nil, err
+	}
+
+	var once sync.Once
+	release := func() error {
+		var err error
+		once.Do(func() {
+			err = m.client.ReleaseTestDatabase(context.Background(), m.hash, test.ID)
+		})
+
+		return err
+	}
+
+	return db, release, nil
+}
+
+// setupTemplate sets up the template unless this already completed. Errors caused by ctx are not recorded, allowing
+// a later call with another context to retry the setup.
+func (m *TestManager) setupTemplate(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.done {
+		return m.setupErr
+	}
+
+	err := m.client.SetupTemplateWithDBClient(ctx, m.hash, m.setup)
+	if err != nil && ctx.Err() != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		return err
+	}
+
+	m.done = true
+	m.setupErr = err
+
+	return err
+}
@@ -0,0 +1,169 @@
+package integresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTestManager(t *testing.T) {
+	t.Parallel()
+
+	var (
+		initialized int32
+		nextID      int32
+		recreated   int32
+	)
+
+	c := newStubClientWithConfig(t, ClientConfig{DriverName: "integresql_stub"}, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/templates":
+			if atomic.AddInt32(&initialized, 1) > 1 {
+				w.WriteHeader(http.StatusLocked)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"database":{"templateHash":"manager","config":{"host":"localhost","port":5432,"username":"user","password":"pass","database":"integresql_template_manager"}}}`))
+		case r.Method == http.MethodPut:
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodGet:
+			n := atomic.AddInt32(&nextID, 1)
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"id":%d,"database":{"templateHash":"manager","config":{"host":"localhost","port":5432,"username":"user","password":"pass","database":"integresql_test_manager_%03d"}}}`, n, n)
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/recreate"):
+			atomic.AddInt32(&recreated, 1)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("invalid request, got %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	var setups int32
+	m := NewTestManager(c, "manager", func(db *sql.DB) error {
+		atomic.AddInt32(&setups, 1)
+		_, err := db.Exec("CREATE TABLE pilots (id int)")
+		return err
+	})
+
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			db, release, err := m.Acquire(ctx)
+			if err != nil {
+				t.Errorf("failed to acquire test database: %v", err)
+				return
+			}
+
+			if err := db.PingContext(ctx); err != nil {
+				t.Errorf("failed to ping test database: %v", err)
+			}
+
+			if err := release(); err != nil {
+				t.Errorf("failed to release test database: %v", err)
+			}
+
+			// releasing twice must not recreate the test database again
+			_ = release()
+		}()
+	}
+
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&setups); n != 1 {
+		t.Errorf("invalid number of setups, got %d, want %d", n, 1)
+	}
+
+	if n := atomic.LoadInt32(&recreated); n != 8 {
+		t.Errorf("invalid number of recreated test databases, got %d, want %d", n, 8)
+	}
+}
+
+func TestTestManagerSetupFailed(t *testing.T) {
+	t.Parallel()
+
+	var initialized int32
+	c := newStubClientWithConfig(t, ClientConfig{DriverName: "integresql_stub"}, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("invalid request, got %s %s", r.Method, r.URL.Path)
+		}
+		atomic.AddInt32(&initialized, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"database":{"templateHash":"failing","config":{"host":"localhost","port":5432,"username":"user","password":"pass","database":"integresql_template_failing"}}}`))
+	})
+
+	errSetup := errors.New("migrations failed")
+	m := NewTestManager(c, "failing", func(db *sql.DB) error {
+		return errSetup
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := m.Acquire(context.Background()); !errors.Is(err, errSetup) {
+			t.Errorf("invalid error, got %v, want %v", err, errSetup)
+		}
+	}
+
+	if n := atomic.LoadInt32(&initialized); n != 1 {
+		t.Errorf("invalid number of initialize requests, got %d, want %d", n, 1)
+	}
+}
+
+func TestTestManagerSetupContextCancelled(t *testing.T) {
+	t.Parallel()
+
+	var initialized int32
+	c := newStubClientWithConfig(t, ClientConfig{DriverName: "integresql_stub"}, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/templates":
+			atomic.AddInt32(&initialized, 1)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"database":{"templateHash":"cancelled","config":{"host":"localhost","port":5432,"username":"user","password":"pass","database":"integresql_template_cancelled"}}}`))
+		case r.Method == http.MethodPut:
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id":1,"database":{"templateHash":"cancelled","config":{"host":"localhost","port":5432,"username":"user","password":"pass","database":"integresql_test_cancelled_001"}}}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	m := NewTestManager(c, "cancelled", func(db *sql.DB) error {
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, _, err := m.Acquire(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("invalid error, got %v, want %v", err, context.Canceled)
+	}
+
+	// the cancelled setup must not be cached, the next call retries it
+	db, release, err := m.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("failed to acquire test database: %v", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		t.Errorf("failed to ping test database: %v", err)
+	}
+
+	if err := release(); err != nil {
+		t.Errorf("failed to release test database: %v", err)
+	}
+
+	if n := atomic.LoadInt32(&initialized); n != 1 {
+		t.Errorf("invalid number of initialize requests, got %d, want %d", n, 1)
+	}
+}